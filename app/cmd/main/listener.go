@@ -0,0 +1,117 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"stats-service/internal/config"
+	"strconv"
+)
+
+// newListener creates the listener described by the listen config: a Unix domain socket
+// for the sock type, otherwise a TCP socket, wrapped with TLS when it is enabled.
+func newListener(cfg *config.Config) (net.Listener, error) {
+	if cfg.Listen.Type == config.ListenTypeSock {
+		return newSocketListener(cfg.Listen.SocketPath, cfg.Listen.SocketMode)
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort(cfg.Listen.BindIP, cfg.Listen.Port))
+	if err != nil {
+		return nil, err
+	}
+
+	if !cfg.Listen.TLS.Enabled {
+		return listener, nil
+	}
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+	return tls.NewListener(listener, tlsConfig), nil
+}
+
+func newSocketListener(path, mode string) (net.Listener, error) {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid socket mode %s: %w", mode, err)
+	}
+
+	// a socket file left by a previous run that was not shut down cleanly blocks the bind
+	if info, statErr := os.Lstat(path); statErr == nil {
+		if info.Mode()&fs.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	} else if !errors.Is(statErr, fs.ErrNotExist) {
+		return nil, statErr
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = os.Chmod(path, fs.FileMode(perm)); err != nil {
+		_ = listener.Close()
+		return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+	}
+	return listener, nil
+}
+
+func newTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	certificate, err := tls.LoadX509KeyPair(cfg.Listen.TLS.CertFile, cfg.Listen.TLS.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tls certificate: %w", err)
+	}
+
+	minVersion, err := parseTLSVersion(cfg.Listen.TLS.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{certificate},
+		MinVersion:   minVersion,
+	}
+
+	if cfg.Listen.TLS.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.Listen.TLS.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client ca file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in client ca file %s", cfg.Listen.TLS.ClientCAFile)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+		if cfg.Listen.TLS.RequireClientCert {
+			tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+
+	return tlsConfig, nil
+}
+
+func parseTLSVersion(version string) (uint16, error) {
+	switch version {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2", "":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("unsupported tls version: %s", version)
+	}
+}
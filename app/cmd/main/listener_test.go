@@ -0,0 +1,199 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/fs"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"stats-service/internal/config"
+	"testing"
+	"time"
+)
+
+func TestNewSocketListener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.sock")
+
+	listener, err := newSocketListener(path, "0600")
+	if err != nil {
+		t.Fatalf("newSocketListener() error = %v", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("socket file: %v", err)
+	}
+	if info.Mode()&fs.ModeSocket == 0 || info.Mode().Perm() != 0o600 {
+		t.Errorf("socket mode = %s, want a socket with 0600", info.Mode())
+	}
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("failed to connect to the socket: %v", err)
+	}
+	_ = conn.Close()
+
+	// the socket file left behind by a listener that was not closed cleanly is replaced
+	if unixListener, ok := listener.(*net.UnixListener); ok {
+		unixListener.SetUnlinkOnClose(false)
+	}
+	_ = listener.Close()
+	listener, err = newSocketListener(path, "0660")
+	if err != nil {
+		t.Fatalf("newSocketListener() over a stale socket error = %v", err)
+	}
+	_ = listener.Close()
+}
+
+func TestNewSocketListenerErrors(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "regular")
+	if err := os.WriteFile(file, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newSocketListener(file, "0660"); err == nil {
+		t.Errorf("newSocketListener() over a regular file error = nil")
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("regular file was removed: %v", err)
+	}
+	if _, err := newSocketListener(filepath.Join(dir, "app.sock"), "rw"); err == nil {
+		t.Errorf("newSocketListener() with an invalid mode error = nil")
+	}
+}
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    uint16
+		wantErr bool
+	}{
+		{version: "", want: tls.VersionTLS12},
+		{version: "1.0", want: tls.VersionTLS10},
+		{version: "1.1", want: tls.VersionTLS11},
+		{version: "1.2", want: tls.VersionTLS12},
+		{version: "1.3", want: tls.VersionTLS13},
+		{version: "2.0", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, err := parseTLSVersion(tt.version)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTLSVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseTLSVersion() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeCertificate(t, dir)
+
+	cfg := &config.Config{}
+	cfg.Listen.TLS.CertFile = certFile
+	cfg.Listen.TLS.KeyFile = keyFile
+	cfg.Listen.TLS.MinVersion = "1.3"
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		t.Fatalf("newTLSConfig() error = %v", err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS13 || tlsConfig.ClientAuth != tls.NoClientCert {
+		t.Errorf("newTLSConfig() = min version %d, client auth %d", tlsConfig.MinVersion, tlsConfig.ClientAuth)
+	}
+
+	cfg.Listen.TLS.ClientCAFile = certFile
+	if tlsConfig, err = newTLSConfig(cfg); err != nil || tlsConfig.ClientAuth != tls.VerifyClientCertIfGiven {
+		t.Errorf("newTLSConfig() with a client ca = %v, %v", tlsConfig, err)
+	}
+	cfg.Listen.TLS.RequireClientCert = true
+	if tlsConfig, err = newTLSConfig(cfg); err != nil || tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		t.Errorf("newTLSConfig() requiring client certificates = %v, %v", tlsConfig, err)
+	}
+
+	cfg.Listen.TLS.ClientCAFile = keyFile
+	if _, err = newTLSConfig(cfg); err == nil {
+		t.Errorf("newTLSConfig() with a client ca file without certificates error = nil")
+	}
+	cfg.Listen.TLS.KeyFile = filepath.Join(dir, "missing.pem")
+	if _, err = newTLSConfig(cfg); err == nil {
+		t.Errorf("newTLSConfig() with a missing key error = nil")
+	}
+}
+
+func TestNewListenerTLS(t *testing.T) {
+	certFile, keyFile := writeCertificate(t, t.TempDir())
+	cfg := &config.Config{}
+	cfg.Listen.Type = config.ListenTypePort
+	cfg.Listen.BindIP = "127.0.0.1"
+	cfg.Listen.Port = "0"
+	cfg.Listen.TLS.Enabled = true
+	cfg.Listen.TLS.CertFile = certFile
+	cfg.Listen.TLS.KeyFile = keyFile
+
+	listener, err := newListener(cfg)
+	if err != nil {
+		t.Fatalf("newListener() error = %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		_ = conn.(*tls.Conn).Handshake()
+		_ = conn.Close()
+	}()
+
+	conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("tls handshake failed: %v", err)
+	}
+	_ = conn.Close()
+}
+
+// writeCertificate writes a self-signed certificate for localhost and its key to dir.
+func writeCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err = os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
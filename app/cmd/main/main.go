@@ -3,6 +3,7 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
 	httpSwagger "github.com/swaggo/http-swagger"
@@ -14,10 +15,17 @@ import (
 	"stats-service/internal/controller"
 	"stats-service/internal/domain/service"
 	"stats-service/internal/storage/db"
+	"stats-service/internal/storage/migrations"
+	"stats-service/pkg/api/compress"
+	"stats-service/pkg/api/cors"
 	"stats-service/pkg/logging"
 	"stats-service/pkg/metric"
 	"stats-service/pkg/postgresql"
 	"stats-service/pkg/shutdown"
+	"stats-service/pkg/tracing"
+	"stats-service/pkg/webhook"
+	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -34,12 +42,72 @@ import (
 // @Host 		localhost:10003
 // @BasePath 	/api
 func main() {
+	configPath := flag.String("config", "", fmt.Sprintf("path to the config file (default: $%s or %s)",
+		config.PathEnv, config.DefaultPath))
+	flag.Parse()
+
 	logging.InitLogger()
+	if flag.NArg() > 0 {
+		// commands print their result to stdout, so the logs must not end up there
+		logging.SetOutput(os.Stderr)
+		os.Exit(runCommand(flag.Args(), *configPath))
+	}
 	logger := logging.GetLogger()
 	logger.Info("logger initialized")
 
 	logger.Info("config initializing")
-	cfg := config.GetConfig()
+	resolvedConfigPath := config.ResolvePath(*configPath)
+	cfg, err := config.Load(resolvedConfigPath)
+	if err != nil {
+		logger.Info(config.Description())
+		logger.Fatal(err)
+	}
+	liveConfig := config.NewLive(resolvedConfigPath, cfg, logger)
+	liveConfig.OnReload(func(cfg *config.Config) {
+		if err := logging.SetLevel(cfg.Log.Level); err != nil {
+			logger.Error(err)
+		}
+	})
+	go liveConfig.ReloadOnSignal(syscall.SIGHUP)
+	defaultLimit := func() int {
+		return liveConfig.Get().Pagination.DefaultLimit
+	}
+
+	shutdownManager := shutdown.NewManager(cfg.Shutdown.DrainTimeout, logger)
+	shutdownManager.Register(shutdown.PhaseLogging, "flush logs", func(ctx context.Context) error {
+		logging.Close()
+		return nil
+	})
+
+	logger.Info("logger configuring")
+	if err = logging.Configure(logging.Options{
+		Format:    cfg.Log.Format,
+		Level:     cfg.Log.Level,
+		Output:    cfg.Log.Output,
+		File:      logFileOptions(cfg.Log.File),
+		ErrorFile: logFileOptions(cfg.Log.ErrorFile),
+	}); err != nil {
+		logger.Fatal(err)
+	}
+
+	if cfg.Tracing.Enabled {
+		logger.Info("tracing initializing")
+		tracer, err := tracing.Init(tracing.Options{
+			ServiceName:   cfg.Tracing.ServiceName,
+			Exporter:      cfg.Tracing.Exporter,
+			Endpoint:      cfg.Tracing.Endpoint,
+			FilePath:      cfg.Tracing.FilePath,
+			SampleRatio:   cfg.Tracing.SampleRatio,
+			BatchSize:     cfg.Tracing.BatchSize,
+			FlushInterval: cfg.Tracing.FlushInterval,
+		})
+		if err != nil {
+			logger.Fatal(err)
+		}
+		shutdownManager.Register(shutdown.PhaseStorage, "flush traces", func(ctx context.Context) error {
+			return tracer.Close()
+		})
+	}
 
 	logger.Info("router initializing")
 	router := httprouter.New()
@@ -48,43 +116,209 @@ func main() {
 	router.Handler(http.MethodGet, "/swagger", http.RedirectHandler("/swagger/index.html", http.StatusMovedPermanently))
 	router.Handler(http.MethodGet, "/swagger/*any", httpSwagger.WrapHandler)
 
-	metricHandler := metric.Handler{Logger: logger}
+	metricHandler := metric.NewHandler(logger)
 	metricHandler.Register(router)
+	shutdownManager.Register(shutdown.PhaseReadiness, "mark not ready", func(ctx context.Context) error {
+		metricHandler.SetReady(false)
+		// give load balancers time to notice before the listener is closed
+		select {
+		case <-time.After(cfg.Shutdown.ReadinessDelay):
+		case <-ctx.Done():
+		}
+		return nil
+	})
 
 	logger.Info("storage initializing")
-	postgresClient, err := postgresql.NewClient(context.Background(), 5, *cfg)
+	postgresClient, err := postgresql.NewClient(context.Background(), cfg.Postgres.ConnectAttempts, *cfg)
+	if err != nil {
+		logger.Fatal(err)
+	}
+	replicaRouter, err := postgresql.NewReplicaRouter(postgresClient, *cfg, logger)
 	if err != nil {
 		logger.Fatal(err)
 	}
-	myStorage := db.NewRepository(postgresClient, logger)
+	shutdownManager.Register(shutdown.PhaseStorage, "close read replica pools", func(ctx context.Context) error {
+		return replicaRouter.Close()
+	})
+	shutdownManager.Register(shutdown.PhaseStorage, "close database pool", func(ctx context.Context) error {
+		postgresClient.Close()
+		return nil
+	})
+	if cfg.Migrations.Enabled {
+		logger.Info("applying database migrations")
+		if err = migrations.Migrate(context.Background(), postgresClient, logger); err != nil {
+			logger.Fatal(err)
+		}
+	}
+	slowQueryLog := db.NewSlowQueryLog(db.SlowQueryOptions{
+		Threshold:        cfg.SlowQuery.Threshold,
+		ExplainThreshold: cfg.SlowQuery.ExplainThreshold,
+		RedactArgs:       cfg.SlowQuery.RedactArgs,
+		MaxEntries:       cfg.SlowQuery.MaxEntries,
+	}, logger)
+	myStorage := db.NewRepository(slowQueryLog.Wrap(postgresClient), slowQueryLog.Wrap(replicaRouter.Reader()), logger)
 	myService := service.NewService(myStorage, logger)
-	myHandler := controller.NewHandler(myService, logger)
+	householdService := service.NewHouseholdService(db.NewHouseholdRepository(slowQueryLog.Wrap(postgresClient), logger), logger)
+	myHandler := controller.NewHandler(myService, householdService, defaultLimit, logger)
 	myHandler.Register(router)
 
+	householdHandler := controller.NewHouseholdHandler(householdService, logger)
+	householdHandler.Register(router)
+
+	budgetStorage := db.NewBudgetRepository(slowQueryLog.Wrap(postgresClient), logger)
+	budgetService := service.NewBudgetService(budgetStorage, myStorage, logger)
+	budgetHandler := controller.NewBudgetHandler(budgetService, householdService, logger)
+	budgetHandler.Register(router)
+
+	goalStorage := db.NewGoalRepository(slowQueryLog.Wrap(postgresClient), logger)
+	goalHandler := controller.NewGoalHandler(service.NewGoalService(goalStorage, myStorage, logger), householdService, logger)
+	goalHandler.Register(router)
+
+	alertStorage := db.NewAlertRepository(slowQueryLog.Wrap(postgresClient), logger)
+	alertHandler := controller.NewAlertHandler(service.NewAlertService(alertStorage, budgetStorage,
+		cfg.Alerts.Webhook.AllowPrivateNetworks, logger), logger)
+	alertHandler.Register(router)
+	if cfg.Alerts.Enabled {
+		logger.Info("alert evaluator initializing")
+		webhookClient := webhook.NewClient(webhook.Options{
+			Secret:               cfg.Alerts.Webhook.Secret,
+			Timeout:              cfg.Alerts.Webhook.Timeout,
+			MaxAttempts:          cfg.Alerts.Webhook.MaxAttempts,
+			InitialBackoff:       cfg.Alerts.Webhook.InitialBackoff,
+			MaxBackoff:           cfg.Alerts.Webhook.MaxBackoff,
+			AllowPrivateNetworks: cfg.Alerts.Webhook.AllowPrivateNetworks,
+		}, logger)
+		alertEvaluator := service.NewAlertEvaluator(alertStorage, budgetStorage, myStorage, webhookClient,
+			service.AlertEvaluatorOptions{
+				Workers:   cfg.Alerts.Webhook.Workers,
+				QueueSize: cfg.Alerts.Webhook.QueueSize,
+			}, logger)
+		alertEvaluator.Start(cfg.Alerts.EvaluationInterval)
+		shutdownManager.Register(shutdown.PhaseWorkers, "stop alert evaluator", func(ctx context.Context) error {
+			return alertEvaluator.Close()
+		})
+	}
+
+	adminHandler := controller.NewAdminHandler(myService, slowQueryLog, controller.AdminCredentials{
+		Username: cfg.Admin.Username,
+		Password: cfg.Admin.Password,
+	}, defaultLimit, logger)
+	adminHandler.Register(router)
+
+	var handler http.Handler = tracing.Middleware(router)
+	if cfg.Compression.Enabled {
+		logger.Info("response compression initializing")
+		compressOptions := compress.Options{
+			MinSize:   cfg.Compression.MinSize,
+			GzipLevel: cfg.Compression.GzipLevel,
+			ZstdLevel: cfg.Compression.ZstdLevel,
+		}
+		if err = compressOptions.Validate(); err != nil {
+			logger.Fatal(err)
+		}
+		handler = compress.Middleware(handler, compressOptions)
+	}
+
+	var corsPolicy atomic.Pointer[cors.Policy]
+	corsPolicy.Store(cors.NewPolicy(corsOptions(cfg)))
+	liveConfig.OnReload(func(cfg *config.Config) {
+		corsPolicy.Store(cors.NewPolicy(corsOptions(cfg)))
+	})
+	handler = cors.Middleware(handler, corsPolicy.Load, func(method, path string) bool {
+		handle, _, _ := router.Lookup(method, path)
+		return handle != nil
+	})
+
+	handler = logging.Middleware(handler, logger, routePattern(router))
+
 	logger.Info("start application")
-	start(router, logger, cfg)
+	start(handler, logger, cfg, shutdownManager)
+}
+
+// runCommand executes a subcommand given after the flags and returns the exit code.
+// Supported commands:
+//
+//	config validate [-config path]  reads the config and prints the effective values with secrets masked
+func runCommand(args []string, configPath string) int {
+	if len(args) < 2 || args[0] != "config" || args[1] != "validate" {
+		_, _ = fmt.Fprintf(os.Stderr, "unknown command: %s\nusage: app [-config path] [config validate]\n",
+			strings.Join(args, " "))
+		return 2
+	}
+
+	flags := flag.NewFlagSet("config validate", flag.ContinueOnError)
+	flags.StringVar(&configPath, "config", configPath, "path to the config file")
+	if err := flags.Parse(args[2:]); err != nil {
+		return 2
+	}
+
+	cfg, err := config.Load(config.ResolvePath(configPath))
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "config is invalid: %v\n", err)
+		return 1
+	}
+	if err = cfg.Print(os.Stdout); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
 }
 
-func start(router http.Handler, logger *logging.Logger, cfg *config.Config) {
+func corsOptions(cfg *config.Config) cors.Options {
+	return cors.Options{
+		Enabled:          cfg.CORS.Enabled,
+		AllowedOrigins:   cfg.CORS.AllowedOrigins,
+		AllowedMethods:   cfg.CORS.AllowedMethods,
+		AllowedHeaders:   cfg.CORS.AllowedHeaders,
+		ExposedHeaders:   cfg.CORS.ExposedHeaders,
+		AllowCredentials: cfg.CORS.AllowCredentials,
+		MaxAge:           cfg.CORS.MaxAge,
+	}
+}
+
+func logFileOptions(cfg config.LogFile) logging.FileOptions {
+	return logging.FileOptions{
+		Path:             cfg.Path,
+		MaxSizeMB:        cfg.MaxSizeMB,
+		RotationInterval: cfg.RotationInterval,
+		MaxBackups:       cfg.MaxBackups,
+		Compress:         cfg.Compress,
+	}
+}
+
+func start(router http.Handler, logger *logging.Logger, cfg *config.Config, shutdownManager *shutdown.Manager) {
 	var server *http.Server
 	var listener net.Listener
 	var err error
 
-	logger.Infof("bind application to host: %s and port: %s", cfg.Listen.BindIP, cfg.Listen.Port)
+	if cfg.Listen.Type == config.ListenTypeSock {
+		logger.Infof("bind application to unix socket: %s", cfg.Listen.SocketPath)
+	} else {
+		logger.Infof("bind application to host: %s and port: %s (tls: %t)",
+			cfg.Listen.BindIP, cfg.Listen.Port, cfg.Listen.TLS.Enabled)
+	}
 
-	listener, err = net.Listen("tcp", fmt.Sprintf("%s:%s", cfg.Listen.BindIP, cfg.Listen.Port))
+	listener, err = newListener(cfg)
 	if err != nil {
 		logger.Fatal(err)
 	}
 
 	server = &http.Server{
-		Handler:      router,
-		WriteTimeout: 15 * time.Second,
-		ReadTimeout:  15 * time.Second,
+		Handler:           router,
+		ReadTimeout:       cfg.Listen.ReadTimeout,
+		ReadHeaderTimeout: cfg.Listen.ReadHeaderTimeout,
+		WriteTimeout:      cfg.Listen.WriteTimeout,
+		IdleTimeout:       cfg.Listen.IdleTimeout,
 	}
 
-	go shutdown.Graceful([]os.Signal{syscall.SIGABRT, syscall.SIGQUIT, syscall.SIGHUP, os.Interrupt, syscall.SIGTERM},
-		server)
+	shutdownManager.Register(shutdown.PhaseServer, "drain http server", func(ctx context.Context) error {
+		if err := server.Shutdown(ctx); err != nil {
+			logger.Warnf("drain deadline exceeded, closing remaining connections: %v", err)
+			return server.Close()
+		}
+		return nil
+	})
+	go shutdownManager.Graceful([]os.Signal{syscall.SIGABRT, syscall.SIGQUIT, os.Interrupt, syscall.SIGTERM})
 
 	logger.Info("application initialized and started")
 
@@ -92,8 +326,39 @@ func start(router http.Handler, logger *logging.Logger, cfg *config.Config) {
 		switch {
 		case errors.Is(err, http.ErrServerClosed):
 			logger.Warn("server shutdown")
+			<-shutdownManager.Done()
 		default:
 			logger.Fatal(err)
 		}
 	}
 }
+
+// routePattern returns a function resolving the pattern of the route matching a request by putting
+// the path parameters found by the router back in place of their values.
+func routePattern(router *httprouter.Router) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		handle, params, _ := router.Lookup(r.Method, r.URL.Path)
+		if handle == nil {
+			return "unmatched"
+		}
+
+		segments := strings.Split(r.URL.Path, "/")
+		next := 0
+		for _, param := range params {
+			if strings.HasPrefix(param.Value, "/") {
+				// a catch-all parameter takes the rest of the path
+				rest := strings.Split(param.Value, "/")
+				segments = append(segments[:len(segments)-len(rest)+1], "*"+param.Key)
+				break
+			}
+			for ; next < len(segments); next++ {
+				if segments[next] == param.Value {
+					segments[next] = ":" + param.Key
+					next++
+					break
+				}
+			}
+		}
+		return strings.Join(segments, "/")
+	}
+}
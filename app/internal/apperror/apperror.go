@@ -6,7 +6,9 @@ import (
 )
 
 var (
-	ErrNotFound = NewAppError("SS-000404", "not found", "not found")
+	ErrNotFound     = NewAppError("SS-000404", "not found", "not found")
+	ErrUnauthorized = NewAppError("SS-000401", "unauthorized", "valid credentials are required")
+	ErrForbidden    = NewAppError("SS-000403", "forbidden", "access to the resource is not allowed")
 )
 
 type ErrorFields map[string]string
@@ -20,6 +20,16 @@ func Middleware(h appHandler) http.HandlerFunc {
 					_, _ = w.Write(ErrNotFound.Marshal())
 					return
 				}
+				if errors.Is(err, ErrUnauthorized) {
+					w.WriteHeader(http.StatusUnauthorized)
+					_, _ = w.Write(ErrUnauthorized.Marshal())
+					return
+				}
+				if errors.Is(err, ErrForbidden) {
+					w.WriteHeader(http.StatusForbidden)
+					_, _ = w.Write(ErrForbidden.Marshal())
+					return
+				}
 
 				w.WriteHeader(http.StatusBadRequest)
 				_, _ = w.Write(appErr.Marshal())
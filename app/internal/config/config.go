@@ -1,39 +1,378 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
+	"gopkg.in/yaml.v3"
+	"io"
+	"net"
+	"os"
+	"reflect"
 	"stats-service/pkg/logging"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
+)
+
+const (
+	ListenTypePort = "port"
+	ListenTypeSock = "sock"
+
+	DefaultPath = "config/local.yml"
+	PathEnv     = "CONFIG_PATH"
+
+	fileEnvSuffix = "_FILE"
+	secretMask    = "******"
 )
 
 type Config struct {
 	Listen struct {
-		Type   string `yaml:"type" env-default:"port"`
-		BindIP string `yaml:"bind_ip" env-default:"localhost"`
-		Port   string `yaml:"port" env-default:"8080"`
-	}
+		Type              string        `yaml:"type" env:"LISTEN_TYPE" env-default:"port"`
+		BindIP            string        `yaml:"bind_ip" env:"LISTEN_BIND_IP" env-default:"localhost"`
+		Port              string        `yaml:"port" env:"LISTEN_PORT" env-default:"8080"`
+		SocketPath        string        `yaml:"socket_path" env:"LISTEN_SOCKET_PATH" env-default:"app.sock"`
+		SocketMode        string        `yaml:"socket_mode" env:"LISTEN_SOCKET_MODE" env-default:"0660"`
+		ReadTimeout       time.Duration `yaml:"read_timeout" env:"LISTEN_READ_TIMEOUT" env-default:"15s"`
+		ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"LISTEN_READ_HEADER_TIMEOUT" env-default:"5s"`
+		WriteTimeout      time.Duration `yaml:"write_timeout" env:"LISTEN_WRITE_TIMEOUT" env-default:"15s"`
+		IdleTimeout       time.Duration `yaml:"idle_timeout" env:"LISTEN_IDLE_TIMEOUT" env-default:"60s"`
+		TLS               struct {
+			Enabled           bool   `yaml:"enabled" env:"LISTEN_TLS_ENABLED" env-default:"false"`
+			CertFile          string `yaml:"cert_file" env:"LISTEN_TLS_CERT_FILE"`
+			KeyFile           string `yaml:"key_file" env:"LISTEN_TLS_KEY_FILE"`
+			MinVersion        string `yaml:"min_version" env:"LISTEN_TLS_MIN_VERSION" env-default:"1.2"`
+			ClientCAFile      string `yaml:"client_ca_file" env:"LISTEN_TLS_CLIENT_CA_FILE"`
+			RequireClientCert bool   `yaml:"require_client_cert" env:"LISTEN_TLS_REQUIRE_CLIENT_CERT" env-default:"false"`
+		} `yaml:"tls"`
+	} `yaml:"listen"`
 	Postgres struct {
-		Host     string `yaml:"host" env-required:"true"`
-		Port     string `yaml:"port" env-required:"true"`
-		Database string `yaml:"database" env-required:"true"`
-		Username string `yaml:"username"`
-		Password string `yaml:"password"`
+		Host                 string           `yaml:"host" env:"POSTGRES_HOST" env-required:"true"`
+		Port                 string           `yaml:"port" env:"POSTGRES_PORT" env-required:"true"`
+		Database             string           `yaml:"database" env:"POSTGRES_DATABASE" env-required:"true"`
+		Username             string           `yaml:"username" env:"POSTGRES_USERNAME"`
+		Password             string           `yaml:"password" env:"POSTGRES_PASSWORD" secret:"true"`
+		SSLMode              string           `yaml:"ssl_mode" env:"POSTGRES_SSL_MODE" env-default:"prefer"`
+		ApplicationName      string           `yaml:"application_name" env:"POSTGRES_APPLICATION_NAME" env-default:"stats-service"`
+		MaxConns             int32            `yaml:"max_conns" env:"POSTGRES_MAX_CONNS" env-default:"10"`
+		MinConns             int32            `yaml:"min_conns" env:"POSTGRES_MIN_CONNS" env-default:"0"`
+		MaxConnLifetime      time.Duration    `yaml:"max_conn_lifetime" env:"POSTGRES_MAX_CONN_LIFETIME" env-default:"1h"`
+		MaxConnIdleTime      time.Duration    `yaml:"max_conn_idle_time" env:"POSTGRES_MAX_CONN_IDLE_TIME" env-default:"30m"`
+		StatementTimeout     time.Duration    `yaml:"statement_timeout" env:"POSTGRES_STATEMENT_TIMEOUT" env-default:"0s"`
+		ConnectTimeout       time.Duration    `yaml:"connect_timeout" env:"POSTGRES_CONNECT_TIMEOUT" env-default:"5s"`
+		ConnectAttempts      int              `yaml:"connect_attempts" env:"POSTGRES_CONNECT_ATTEMPTS" env-default:"5"`
+		RetryDelay           time.Duration    `yaml:"retry_delay" env:"POSTGRES_RETRY_DELAY" env-default:"5s"`
+		Replicas             PostgresReplicas `yaml:"replicas" env:"POSTGRES_REPLICAS"`
+		ReplicaCheckInterval time.Duration    `yaml:"replica_check_interval" env:"POSTGRES_REPLICA_CHECK_INTERVAL" env-default:"10s"`
 	} `yaml:"postgres" env-required:"true"`
+	SlowQuery struct {
+		Threshold        time.Duration `yaml:"threshold" env:"SLOW_QUERY_THRESHOLD" env-default:"200ms"`
+		ExplainThreshold time.Duration `yaml:"explain_threshold" env:"SLOW_QUERY_EXPLAIN_THRESHOLD" env-default:"1s"`
+		RedactArgs       bool          `yaml:"redact_args" env:"SLOW_QUERY_REDACT_ARGS" env-default:"true"`
+		MaxEntries       int           `yaml:"max_entries" env:"SLOW_QUERY_MAX_ENTRIES" env-default:"100"`
+	} `yaml:"slow_query"`
+	Admin struct {
+		Username string `yaml:"username" env:"ADMIN_USERNAME"`
+		Password string `yaml:"password" env:"ADMIN_PASSWORD" secret:"true"`
+	} `yaml:"admin"`
+	Log struct {
+		Format    string  `yaml:"format" env:"LOG_FORMAT" env-default:"text"`
+		Level     string  `yaml:"level" env:"LOG_LEVEL" env-default:"trace" reload:"true"`
+		Output    string  `yaml:"output" env:"LOG_OUTPUT" env-default:"both"`
+		File      LogFile `yaml:"file" env-prefix:"LOG_FILE_"`
+		ErrorFile LogFile `yaml:"error_file" env-prefix:"LOG_ERROR_FILE_"`
+	} `yaml:"log"`
+	Tracing struct {
+		Enabled       bool          `yaml:"enabled" env:"TRACING_ENABLED" env-default:"false"`
+		ServiceName   string        `yaml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"stats-service"`
+		Exporter      string        `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"otlp"`
+		Endpoint      string        `yaml:"endpoint" env:"TRACING_ENDPOINT" env-default:"http://localhost:4318/v1/traces"`
+		FilePath      string        `yaml:"file_path" env:"TRACING_FILE_PATH" env-default:"traces/spans.jsonl"`
+		SampleRatio   float64       `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1"`
+		BatchSize     int           `yaml:"batch_size" env:"TRACING_BATCH_SIZE" env-default:"512"`
+		FlushInterval time.Duration `yaml:"flush_interval" env:"TRACING_FLUSH_INTERVAL" env-default:"5s"`
+	} `yaml:"tracing"`
+	Shutdown struct {
+		DrainTimeout   time.Duration `yaml:"drain_timeout" env:"SHUTDOWN_DRAIN_TIMEOUT" env-default:"30s"`
+		ReadinessDelay time.Duration `yaml:"readiness_delay" env:"SHUTDOWN_READINESS_DELAY" env-default:"0s"`
+	} `yaml:"shutdown"`
+	Pagination struct {
+		DefaultLimit int `yaml:"default_limit" env:"PAGINATION_DEFAULT_LIMIT" env-default:"20" reload:"true"`
+	} `yaml:"pagination"`
+	CORS struct {
+		Enabled          bool          `yaml:"enabled" env:"CORS_ENABLED" env-default:"false"`
+		AllowedOrigins   []string      `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+		AllowedMethods   []string      `yaml:"allowed_methods" env:"CORS_ALLOWED_METHODS" env-default:"GET,HEAD,POST,PUT,DELETE,OPTIONS"`
+		AllowedHeaders   []string      `yaml:"allowed_headers" env:"CORS_ALLOWED_HEADERS" env-default:"Accept,Authorization,Content-Type,If-None-Match,X-Request-ID,X-User-UUID,traceparent"`
+		ExposedHeaders   []string      `yaml:"exposed_headers" env:"CORS_EXPOSED_HEADERS" env-default:"ETag,X-Request-ID"`
+		AllowCredentials bool          `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" env-default:"false"`
+		MaxAge           time.Duration `yaml:"max_age" env:"CORS_MAX_AGE" env-default:"10m"`
+	} `yaml:"cors" reload:"true"`
+	Compression struct {
+		Enabled   bool `yaml:"enabled" env:"COMPRESSION_ENABLED" env-default:"true"`
+		MinSize   int  `yaml:"min_size" env:"COMPRESSION_MIN_SIZE" env-default:"1024"`
+		GzipLevel int  `yaml:"gzip_level" env:"COMPRESSION_GZIP_LEVEL" env-default:"6"`
+		ZstdLevel int  `yaml:"zstd_level" env:"COMPRESSION_ZSTD_LEVEL" env-default:"3"`
+	} `yaml:"compression"`
+	Alerts struct {
+		Enabled            bool          `yaml:"enabled" env:"ALERTS_ENABLED" env-default:"false"`
+		EvaluationInterval time.Duration `yaml:"evaluation_interval" env:"ALERTS_EVALUATION_INTERVAL" env-default:"5m"`
+		Webhook            struct {
+			Secret               string        `yaml:"secret" env:"ALERTS_WEBHOOK_SECRET" secret:"true"`
+			Timeout              time.Duration `yaml:"timeout" env:"ALERTS_WEBHOOK_TIMEOUT" env-default:"10s"`
+			MaxAttempts          int           `yaml:"max_attempts" env:"ALERTS_WEBHOOK_MAX_ATTEMPTS" env-default:"5"`
+			InitialBackoff       time.Duration `yaml:"initial_backoff" env:"ALERTS_WEBHOOK_INITIAL_BACKOFF" env-default:"1s"`
+			MaxBackoff           time.Duration `yaml:"max_backoff" env:"ALERTS_WEBHOOK_MAX_BACKOFF" env-default:"1m"`
+			Workers              int           `yaml:"workers" env:"ALERTS_WEBHOOK_WORKERS" env-default:"4"`
+			QueueSize            int           `yaml:"queue_size" env:"ALERTS_WEBHOOK_QUEUE_SIZE" env-default:"100"`
+			AllowPrivateNetworks bool          `yaml:"allow_private_networks" env:"ALERTS_WEBHOOK_ALLOW_PRIVATE_NETWORKS" env-default:"false"`
+		} `yaml:"webhook"`
+	} `yaml:"alerts"`
+	Migrations struct {
+		Enabled bool `yaml:"enabled" env:"MIGRATIONS_ENABLED" env-default:"true"`
+	} `yaml:"migrations"`
+}
+
+type PostgresReplica struct {
+	Host string `yaml:"host"`
+	Port string `yaml:"port"`
+}
+
+type PostgresReplicas []PostgresReplica
+
+// SetValue parses replicas from an environment variable in the host:port,host:port form.
+func (r *PostgresReplicas) SetValue(value string) error {
+	replicas := make(PostgresReplicas, 0)
+	for _, address := range strings.Split(value, ",") {
+		address = strings.TrimSpace(address)
+		if address == "" {
+			continue
+		}
+		host, port, err := net.SplitHostPort(address)
+		if err != nil {
+			return fmt.Errorf("invalid replica address %q: %w", address, err)
+		}
+		replicas = append(replicas, PostgresReplica{Host: host, Port: port})
+	}
+	*r = replicas
+	return nil
+}
+
+type LogFile struct {
+	Path             string        `yaml:"path" env:"PATH"`
+	MaxSizeMB        int           `yaml:"max_size_mb" env:"MAX_SIZE_MB" env-default:"100"`
+	RotationInterval time.Duration `yaml:"rotation_interval" env:"ROTATION_INTERVAL" env-default:"24h"`
+	MaxBackups       int           `yaml:"max_backups" env:"MAX_BACKUPS" env-default:"7"`
+	Compress         bool          `yaml:"compress" env:"COMPRESS" env-default:"true"`
+}
+
+// ResolvePath picks the config file path: the -config flag value if given,
+// then the CONFIG_PATH environment variable, then DefaultPath.
+func ResolvePath(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if path := os.Getenv(PathEnv); path != "" {
+		return path
+	}
+	return DefaultPath
+}
+
+// Load reads the config file at path and applies environment variable overrides on top of it.
+// Every variable can also be given as NAME_FILE pointing to a file with the value, which is
+// how secrets are usually mounted. Without a file at the default path only the environment is used.
+func Load(path string) (*Config, error) {
+	logger := logging.GetLogger()
+	logger.Infof("read application config from %s", path)
+
+	if err := loadFileEnv(reflect.TypeOf(Config{}), ""); err != nil {
+		return nil, err
+	}
+
+	cfg := &Config{}
+	var err error
+	if _, statErr := os.Stat(path); errors.Is(statErr, os.ErrNotExist) && path == DefaultPath {
+		logger.Warnf("config file %s not found, reading config from environment only", path)
+		err = cleanenv.ReadEnv(cfg)
+	} else {
+		err = cleanenv.ReadConfig(path, cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Description lists the supported environment variables.
+func Description() string {
+	help, _ := cleanenv.GetDescription(&Config{}, nil)
+	return help
+}
+
+func (c *Config) Validate() error {
+	switch c.Listen.Type {
+	case ListenTypePort:
+	case ListenTypeSock:
+		if c.Listen.SocketPath == "" {
+			return fmt.Errorf("listen socket_path is required for the %s listen type", ListenTypeSock)
+		}
+		if _, err := strconv.ParseUint(c.Listen.SocketMode, 8, 32); err != nil {
+			return fmt.Errorf("invalid listen socket_mode: %s", c.Listen.SocketMode)
+		}
+		if c.Listen.TLS.Enabled {
+			return fmt.Errorf("tls is only supported for the %s listen type", ListenTypePort)
+		}
+	default:
+		return fmt.Errorf("invalid listen type: %s", c.Listen.Type)
+	}
+	if c.Listen.TLS.Enabled {
+		if c.Listen.TLS.CertFile == "" || c.Listen.TLS.KeyFile == "" {
+			return fmt.Errorf("listen tls cert_file and key_file are required when tls is enabled")
+		}
+		if c.Listen.TLS.RequireClientCert && c.Listen.TLS.ClientCAFile == "" {
+			return fmt.Errorf("listen tls client_ca_file is required to verify client certificates")
+		}
+	}
+	if c.Postgres.MinConns > c.Postgres.MaxConns {
+		return fmt.Errorf("postgres min_conns %d is greater than max_conns %d", c.Postgres.MinConns, c.Postgres.MaxConns)
+	}
+	switch c.Log.Output {
+	case logging.OutputStdout, logging.OutputFile, logging.OutputBoth:
+	default:
+		return fmt.Errorf("invalid log output: %s", c.Log.Output)
+	}
+	if err := logging.ValidateLevel(c.Log.Level); err != nil {
+		return err
+	}
+	switch c.Log.Format {
+	case logging.FormatText, logging.FormatJSON:
+	default:
+		return fmt.Errorf("invalid log format: %s", c.Log.Format)
+	}
+	if c.Pagination.DefaultLimit <= 0 {
+		return fmt.Errorf("pagination default_limit should be positive: %d", c.Pagination.DefaultLimit)
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		return fmt.Errorf("tracing sample_ratio should be between 0 and 1: %v", c.Tracing.SampleRatio)
+	}
+	if c.CORS.AllowCredentials {
+		for _, origin := range c.CORS.AllowedOrigins {
+			if origin == "*" {
+				return fmt.Errorf("cors allowed_origins \"*\" cannot be combined with allow_credentials")
+			}
+		}
+	}
+	if c.Alerts.Enabled {
+		if c.Alerts.Webhook.Secret == "" {
+			return fmt.Errorf("alerts webhook secret is required when alerts are enabled")
+		}
+		if c.Alerts.EvaluationInterval <= 0 {
+			return fmt.Errorf("alerts evaluation_interval should be positive: %s", c.Alerts.EvaluationInterval)
+		}
+		if c.Alerts.Webhook.Workers <= 0 {
+			return fmt.Errorf("alerts webhook workers should be positive: %d", c.Alerts.Webhook.Workers)
+		}
+		if c.Alerts.Webhook.QueueSize < 0 {
+			return fmt.Errorf("alerts webhook queue_size should not be negative: %d", c.Alerts.Webhook.QueueSize)
+		}
+	}
+	return nil
+}
+
+// Masked returns a copy of the config with every field tagged as secret replaced by a mask.
+func (c *Config) Masked() Config {
+	masked := *c
+	maskSecrets(reflect.ValueOf(&masked).Elem())
+	return masked
 }
 
-var instance *Config
-var once sync.Once
+// Print writes the effective config as YAML with secrets masked.
+func (c *Config) Print(w io.Writer) error {
+	masked := c.Masked()
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&masked); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+	return encoder.Close()
+}
 
-func GetConfig() *Config {
-	once.Do(func() {
-		logger := logging.GetLogger()
-		logger.Info("read application config")
-		instance = &Config{}
-		if err := cleanenv.ReadConfig("config/local.yml", instance); err != nil {
-			help, _ := cleanenv.GetDescription(instance, nil)
-			logger.Info(help)
-			logger.Fatal(err)
+func maskSecrets(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if v.Type().Field(i).Tag.Get("secret") == "true" && field.Kind() == reflect.String {
+				if field.String() != "" {
+					field.SetString(secretMask)
+				}
+				continue
+			}
+			maskSecrets(field)
 		}
-	})
-	return instance
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return
+		}
+		// copy the slice so the masked config does not share elements with the original
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		reflect.Copy(copied, v)
+		v.Set(copied)
+		for i := 0; i < v.Len(); i++ {
+			maskSecrets(v.Index(i))
+		}
+	}
+}
+
+// fileEnv holds the names of the environment variables set by loadFileEnv, which are read
+// from their files again on every Load so that a reload picks up rotated secrets.
+var fileEnv = struct {
+	sync.Mutex
+	names map[string]bool
+}{names: map[string]bool{}}
+
+// loadFileEnv sets every environment variable NAME used by the config from the file
+// named in NAME_FILE, unless NAME itself is set by the environment.
+func loadFileEnv(t reflect.Type, prefix string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			if err := loadFileEnv(field.Type, prefix+field.Tag.Get("env-prefix")); err != nil {
+				return err
+			}
+			continue
+		}
+
+		envTag := field.Tag.Get("env")
+		if envTag == "" {
+			continue
+		}
+		for _, name := range strings.Split(envTag, ",") {
+			name = prefix + strings.TrimSpace(name)
+			filePath := os.Getenv(name + fileEnvSuffix)
+			fileEnv.Lock()
+			fromFile := fileEnv.names[name]
+			fileEnv.Unlock()
+			if filePath == "" || (os.Getenv(name) != "" && !fromFile) {
+				continue
+			}
+
+			value, err := os.ReadFile(filePath)
+			if err != nil {
+				return fmt.Errorf("failed to read %s%s: %w", name, fileEnvSuffix, err)
+			}
+			if err = os.Setenv(name, strings.TrimRight(string(value), "\r\n")); err != nil {
+				return fmt.Errorf("failed to set %s: %w", name, err)
+			}
+			fileEnv.Lock()
+			fileEnv.names[name] = true
+			fileEnv.Unlock()
+		}
+	}
+	return nil
 }
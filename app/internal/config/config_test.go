@@ -0,0 +1,176 @@
+package config
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"stats-service/pkg/logging"
+	"testing"
+)
+
+const testConfig = `
+listen:
+  type: port
+  port: "8080"
+postgres:
+  host: localhost
+  port: "5432"
+  database: stats
+  password: secret
+log:
+  level: info
+  output: stdout
+pagination:
+  default_limit: 20
+`
+
+func init() {
+	logging.InitLogger()
+	logging.SetOutput(io.Discard)
+}
+
+// writeConfig writes content to a config file in a temporary directory and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	writeFile(t, path, content)
+	return path
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func loadConfig(t *testing.T, path string) *Config {
+	t.Helper()
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *Config)
+		wantErr bool
+	}{
+		{name: "valid", modify: func(cfg *Config) {}},
+		{name: "unknown listen type", modify: func(cfg *Config) { cfg.Listen.Type = "pipe" }, wantErr: true},
+		{name: "socket", modify: func(cfg *Config) { cfg.Listen.Type = ListenTypeSock }},
+		{name: "socket without path", modify: func(cfg *Config) {
+			cfg.Listen.Type = ListenTypeSock
+			cfg.Listen.SocketPath = ""
+		}, wantErr: true},
+		{name: "socket with invalid mode", modify: func(cfg *Config) {
+			cfg.Listen.Type = ListenTypeSock
+			cfg.Listen.SocketMode = "0999"
+		}, wantErr: true},
+		{name: "socket with tls", modify: func(cfg *Config) {
+			cfg.Listen.Type = ListenTypeSock
+			cfg.Listen.TLS.Enabled = true
+			cfg.Listen.TLS.CertFile = "cert.pem"
+			cfg.Listen.TLS.KeyFile = "key.pem"
+		}, wantErr: true},
+		{name: "tls", modify: func(cfg *Config) {
+			cfg.Listen.TLS.Enabled = true
+			cfg.Listen.TLS.CertFile = "cert.pem"
+			cfg.Listen.TLS.KeyFile = "key.pem"
+		}},
+		{name: "tls without key", modify: func(cfg *Config) {
+			cfg.Listen.TLS.Enabled = true
+			cfg.Listen.TLS.CertFile = "cert.pem"
+		}, wantErr: true},
+		{name: "tls without cert", modify: func(cfg *Config) {
+			cfg.Listen.TLS.Enabled = true
+			cfg.Listen.TLS.KeyFile = "key.pem"
+		}, wantErr: true},
+		{name: "client certificates without ca", modify: func(cfg *Config) {
+			cfg.Listen.TLS.Enabled = true
+			cfg.Listen.TLS.CertFile = "cert.pem"
+			cfg.Listen.TLS.KeyFile = "key.pem"
+			cfg.Listen.TLS.RequireClientCert = true
+		}, wantErr: true},
+		{name: "min conns above max conns", modify: func(cfg *Config) {
+			cfg.Postgres.MinConns = 20
+			cfg.Postgres.MaxConns = 10
+		}, wantErr: true},
+		{name: "unknown log output", modify: func(cfg *Config) { cfg.Log.Output = "syslog" }, wantErr: true},
+		{name: "unknown log level", modify: func(cfg *Config) { cfg.Log.Level = "loud" }, wantErr: true},
+		{name: "unknown log format", modify: func(cfg *Config) { cfg.Log.Format = "xml" }, wantErr: true},
+		{name: "zero default limit", modify: func(cfg *Config) { cfg.Pagination.DefaultLimit = 0 }, wantErr: true},
+		{name: "sample ratio above one", modify: func(cfg *Config) { cfg.Tracing.SampleRatio = 1.5 }, wantErr: true},
+		{name: "cors wildcard", modify: func(cfg *Config) { cfg.CORS.AllowedOrigins = []string{"*"} }},
+		{name: "cors wildcard with credentials", modify: func(cfg *Config) {
+			cfg.CORS.AllowedOrigins = []string{"https://app.example.com", "*"}
+			cfg.CORS.AllowCredentials = true
+		}, wantErr: true},
+		{name: "alerts without secret", modify: func(cfg *Config) { cfg.Alerts.Enabled = true }, wantErr: true},
+		{name: "alerts", modify: func(cfg *Config) {
+			cfg.Alerts.Enabled = true
+			cfg.Alerts.Webhook.Secret = "secret"
+		}},
+		{name: "alerts without workers", modify: func(cfg *Config) {
+			cfg.Alerts.Enabled = true
+			cfg.Alerts.Webhook.Secret = "secret"
+			cfg.Alerts.Webhook.Workers = 0
+		}, wantErr: true},
+	}
+	path := writeConfig(t, testConfig)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := loadConfig(t, path)
+			tt.modify(cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadEnvOverride(t *testing.T) {
+	t.Setenv("LOG_LEVEL", "warn")
+	cfg := loadConfig(t, writeConfig(t, testConfig))
+	if cfg.Log.Level != "warn" {
+		t.Errorf("Log.Level = %q, want the environment value %q", cfg.Log.Level, "warn")
+	}
+	if cfg.Postgres.Database != "stats" {
+		t.Errorf("Postgres.Database = %q, want the file value %q", cfg.Postgres.Database, "stats")
+	}
+}
+
+func TestLoadFileEnv(t *testing.T) {
+	secretPath := filepath.Join(t.TempDir(), "admin_password")
+	writeFile(t, secretPath, "first\n")
+	t.Setenv("ADMIN_PASSWORD", "")
+	t.Setenv("ADMIN_PASSWORD_FILE", secretPath)
+	path := writeConfig(t, testConfig)
+
+	if cfg := loadConfig(t, path); cfg.Admin.Password != "first" {
+		t.Fatalf("Admin.Password = %q, want %q", cfg.Admin.Password, "first")
+	}
+
+	// a rotated secret is read again on the next load
+	writeFile(t, secretPath, "second\n")
+	if cfg := loadConfig(t, path); cfg.Admin.Password != "second" {
+		t.Errorf("Admin.Password = %q after rotation, want %q", cfg.Admin.Password, "second")
+	}
+}
+
+func TestMasked(t *testing.T) {
+	cfg := loadConfig(t, writeConfig(t, testConfig))
+	masked := cfg.Masked()
+	if masked.Postgres.Password != secretMask {
+		t.Errorf("masked Postgres.Password = %q, want %q", masked.Postgres.Password, secretMask)
+	}
+	if cfg.Postgres.Password != "secret" {
+		t.Errorf("Masked() changed the original password to %q", cfg.Postgres.Password)
+	}
+	if masked.Admin.Password != "" {
+		t.Errorf("masked empty Admin.Password = %q, want it to stay empty", masked.Admin.Password)
+	}
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"stats-service/pkg/logging"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Live holds the configuration currently in effect. On reload only the fields tagged
+// with reload:"true" are taken from the new file, everything else keeps its value
+// until the application is restarted.
+type Live struct {
+	path    string
+	current atomic.Pointer[Config]
+	logger  *logging.Logger
+
+	mu        sync.Mutex
+	listeners []func(cfg *Config)
+}
+
+func NewLive(path string, cfg *Config, logger *logging.Logger) *Live {
+	live := &Live{
+		path:   path,
+		logger: logger,
+	}
+	live.current.Store(cfg)
+	return live
+}
+
+func (l *Live) Get() *Config {
+	return l.current.Load()
+}
+
+// OnReload registers a function that is called with the new config after every
+// successful reload.
+func (l *Live) OnReload(fn func(cfg *Config)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.listeners = append(l.listeners, fn)
+}
+
+// Reload reads and validates the config file again and swaps in the reloadable settings.
+// It returns the applied changes and the changed settings that need a restart.
+func (l *Live) Reload() (applied, restartRequired []string, err error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	loaded, err := Load(l.path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	old := l.current.Load()
+	merged := *old
+	applied, restartRequired = mergeReloadable(reflect.ValueOf(&merged).Elem(), reflect.ValueOf(loaded).Elem(),
+		"", false, false)
+
+	if len(applied) == 0 {
+		return nil, restartRequired, nil
+	}
+
+	l.current.Store(&merged)
+	for _, listener := range l.listeners {
+		listener(&merged)
+	}
+	return applied, restartRequired, nil
+}
+
+// ReloadOnSignal reloads the config every time one of the signals is received.
+func (l *Live) ReloadOnSignal(signals ...os.Signal) {
+	sigChannel := make(chan os.Signal, 1)
+	signal.Notify(sigChannel, signals...)
+
+	for sig := range sigChannel {
+		l.logger.Infof("Caught signal %s. Reloading config...", sig)
+
+		applied, restartRequired, err := l.Reload()
+		if err != nil {
+			l.logger.Errorf("config reload failed, keeping the current config: %v", err)
+			continue
+		}
+
+		if len(applied) == 0 {
+			l.logger.Info("config reloaded, no reloadable settings changed")
+		} else {
+			l.logger.Infof("config reloaded: %s", strings.Join(applied, "; "))
+		}
+		if len(restartRequired) > 0 {
+			l.logger.Warnf("changed settings require a restart to take effect: %s",
+				strings.Join(restartRequired, "; "))
+		}
+	}
+}
+
+// mergeReloadable copies reloadable fields from src to dst and describes every difference.
+func mergeReloadable(dst, src reflect.Value, path string, reloadable, secret bool) (applied, restartRequired []string) {
+	if dst.Kind() == reflect.Struct {
+		for i := 0; i < dst.NumField(); i++ {
+			field := dst.Type().Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if name == "" {
+				name = strings.ToLower(field.Name)
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+
+			a, r := mergeReloadable(dst.Field(i), src.Field(i), name,
+				reloadable || field.Tag.Get("reload") == "true", secret || field.Tag.Get("secret") == "true")
+			applied = append(applied, a...)
+			restartRequired = append(restartRequired, r...)
+		}
+		return applied, restartRequired
+	}
+
+	if reflect.DeepEqual(dst.Interface(), src.Interface()) {
+		return nil, nil
+	}
+
+	change := fmt.Sprintf("%s: %s -> %s", path, formatValue(dst, secret), formatValue(src, secret))
+	if !reloadable {
+		return nil, []string{change}
+	}
+	dst.Set(src)
+	return []string{change}, nil
+}
+
+func formatValue(v reflect.Value, secret bool) string {
+	if secret {
+		return secretMask
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
@@ -0,0 +1,108 @@
+package config
+
+import (
+	"reflect"
+	"stats-service/pkg/logging"
+	"strings"
+	"testing"
+)
+
+func TestMergeReloadable(t *testing.T) {
+	type nested struct {
+		Value string `yaml:"value"`
+	}
+	type settings struct {
+		Level    string   `yaml:"level" reload:"true"`
+		Port     string   `yaml:"port"`
+		Password string   `yaml:"password" secret:"true"`
+		Token    string   `yaml:"token" reload:"true" secret:"true"`
+		Origins  []string `yaml:"origins" reload:"true"`
+		Group    nested   `yaml:"group" reload:"true"`
+		Other    nested   `yaml:"other"`
+	}
+	dst := settings{Level: "info", Port: "8080", Password: "old", Token: "old", Origins: []string{"a"},
+		Group: nested{Value: "x"}, Other: nested{Value: "x"}}
+	src := settings{Level: "debug", Port: "9090", Password: "new", Token: "new", Origins: []string{"a", "b"},
+		Group: nested{Value: "y"}, Other: nested{Value: "y"}}
+
+	applied, restartRequired := mergeReloadable(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(src), "", false, false)
+
+	want := settings{Level: "debug", Port: "8080", Password: "old", Token: "new", Origins: []string{"a", "b"},
+		Group: nested{Value: "y"}, Other: nested{Value: "x"}}
+	if !reflect.DeepEqual(dst, want) {
+		t.Errorf("merged = %+v, want %+v", dst, want)
+	}
+
+	wantApplied := []string{"level: info -> debug", "token: ****** -> ******", "origins: [a] -> [a b]",
+		"group.value: x -> y"}
+	if !reflect.DeepEqual(applied, wantApplied) {
+		t.Errorf("applied = %q, want %q", applied, wantApplied)
+	}
+	wantRestart := []string{"port: 8080 -> 9090", "password: ****** -> ******", "other.value: x -> y"}
+	if !reflect.DeepEqual(restartRequired, wantRestart) {
+		t.Errorf("restartRequired = %q, want %q", restartRequired, wantRestart)
+	}
+}
+
+func TestMergeReloadableUnchanged(t *testing.T) {
+	dst := struct {
+		Level string `yaml:"level" reload:"true"`
+		Port  string `yaml:"port"`
+	}{Level: "info", Port: "8080"}
+	applied, restartRequired := mergeReloadable(reflect.ValueOf(&dst).Elem(), reflect.ValueOf(dst), "", false, false)
+	if len(applied) != 0 || len(restartRequired) != 0 {
+		t.Errorf("mergeReloadable() = %q, %q, want no changes", applied, restartRequired)
+	}
+}
+
+func TestLiveReload(t *testing.T) {
+	path := writeConfig(t, testConfig)
+	live := NewLive(path, loadConfig(t, path), logging.GetLogger())
+
+	var notified *Config
+	live.OnReload(func(cfg *Config) { notified = cfg })
+
+	applied, restartRequired, err := live.Reload()
+	if err != nil || len(applied) != 0 || len(restartRequired) != 0 || notified != nil {
+		t.Fatalf("Reload() of an unchanged file = %q, %q, %v, want no changes", applied, restartRequired, err)
+	}
+
+	writeFile(t, path, strings.NewReplacer(
+		"level: info", "level: debug",
+		"default_limit: 20", "default_limit: 50",
+		`port: "8080"`, `port: "9090"`,
+		"password: secret", "password: rotated",
+	).Replace(testConfig))
+
+	applied, restartRequired, err = live.Reload()
+	if err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	wantApplied := []string{"log.level: info -> debug", "pagination.default_limit: 20 -> 50"}
+	if !reflect.DeepEqual(applied, wantApplied) {
+		t.Errorf("applied = %q, want %q", applied, wantApplied)
+	}
+	wantRestart := []string{"listen.port: 8080 -> 9090", "postgres.password: ****** -> ******"}
+	if !reflect.DeepEqual(restartRequired, wantRestart) {
+		t.Errorf("restartRequired = %q, want %q", restartRequired, wantRestart)
+	}
+
+	cfg := live.Get()
+	if cfg.Log.Level != "debug" || cfg.Pagination.DefaultLimit != 50 {
+		t.Errorf("reloadable settings = %q, %d, want %q, %d", cfg.Log.Level, cfg.Pagination.DefaultLimit, "debug", 50)
+	}
+	if cfg.Listen.Port != "8080" || cfg.Postgres.Password != "secret" {
+		t.Errorf("restart-only settings = %q, %q, want them unchanged", cfg.Listen.Port, cfg.Postgres.Password)
+	}
+	if notified != cfg {
+		t.Errorf("OnReload listener was not called with the new config")
+	}
+
+	writeFile(t, path, strings.Replace(testConfig, "output: stdout", "output: syslog", 1))
+	if _, _, err = live.Reload(); err == nil {
+		t.Errorf("Reload() of an invalid file error = nil")
+	}
+	if live.Get() != cfg {
+		t.Errorf("an invalid file replaced the current config")
+	}
+}
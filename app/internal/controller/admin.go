@@ -0,0 +1,152 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"stats-service/internal/apperror"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/api/filter"
+	"stats-service/pkg/api/sort"
+	"stats-service/pkg/logging"
+)
+
+const (
+	slowQueriesURL  = "/api/admin/slow-queries"
+	explainStatsURL = "/api/admin/stats/explain"
+)
+
+type AdminCredentials struct {
+	Username string
+	Password string
+}
+
+type adminHandler struct {
+	service      Service
+	slowQueries  SlowQueryLog
+	credentials  AdminCredentials
+	defaultLimit func() int
+	logger       *logging.Logger
+}
+
+func NewAdminHandler(service Service, slowQueries SlowQueryLog, credentials AdminCredentials,
+	defaultLimit func() int, logger *logging.Logger) Handler {
+	return &adminHandler{
+		service:      service,
+		slowQueries:  slowQueries,
+		credentials:  credentials,
+		defaultLimit: defaultLimit,
+		logger:       logger,
+	}
+}
+
+func (h *adminHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, slowQueriesURL, apperror.Middleware(h.GetSlowQueries))
+	router.HandlerFunc(http.MethodGet, explainStatsURL,
+		filter.Middleware(sort.Middleware(apperror.Middleware(h.ExplainStats), entity.DateTime, sort.ASC), h.defaultLimit))
+}
+
+// GetSlowQueries
+// @Summary 	Get slow queries
+// @Description Lists the most recent SQL queries that exceeded the slow query threshold, with EXPLAIN plans when captured.
+// @Tags 		Admin
+// @Produce 	json
+// @Security 	BasicAuth
+// @Success 	200 {array}  entity.SlowQuery "Slow queries, newest first"
+// @Failure 	401 {object} apperror.AppError "Missing or invalid admin credentials"
+// @Failure 	418 {object} apperror.AppError "Something wrong with application logic"
+// @Router /admin/slow-queries [get]
+func (h *adminHandler) GetSlowQueries(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get slow queries")
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := h.authorize(w, r); err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(h.slowQueries.SlowQueries())
+	if err != nil {
+		return fmt.Errorf("failed to marshal slow queries: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get slow queries successfully")
+	return nil
+}
+
+// ExplainStats
+// @Summary 	Explain stats query
+// @Description Builds the SQL query that /stats would run for the same parameters and returns it with its arguments and the Postgres EXPLAIN plan. The query itself is not executed.
+// @Tags 		Admin
+// @Produce 	json
+// @Security 	BasicAuth
+// @Param 		user_uuid 	  path 	   string false  "User UUID"
+// @Param 		category_name path 	   string false  "Category name (supports operators: substr)"
+// @Param 		type	 	  path 	   string false  "Category type"
+// @Param 		category_id   path 	   string false  "Category ID"
+// @Param 		description   path 	   string false  "Description (supports operators: substr)"
+// @Param 		money_sum 	  path 	   string false  "Money sum (supports operators: eq, neq, lt, lte, gt, gte, between)"
+// @Param 		date_time     path 	   string false  "Date and time of operation (supports operators: eq, between; format: yyyy-mm-dd)"
+// @Param 		sort_by 	  path 	   string false  "Field to sort by (money_sum, date_time, description)"
+// @Param 		sort_order 	  path 	   string false  "Sort order (asc, desc)"
+// @Success 	200 {object} entity.QueryPlan "Generated SQL, arguments and plan"
+// @Failure 	400 {object} apperror.AppError "Validation error in filter or sort parameters"
+// @Failure 	401 {object} apperror.AppError "Missing or invalid admin credentials"
+// @Failure 	418 {object} apperror.AppError "Something wrong with application logic"
+// @Router /admin/stats/explain [get]
+func (h *adminHandler) ExplainStats(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Explain stats query")
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := h.authorize(w, r); err != nil {
+		return err
+	}
+
+	var sortOptions sort.Options
+	if options, ok := r.Context().Value(sort.OptionsContextKey).(sort.Options); ok {
+		sortOptions = options
+	}
+
+	filterOptions, err := parseFilterOptions(r)
+	if err != nil {
+		return err
+	}
+
+	queryPlan, err := h.service.Explain(r.Context(), sortOptions, filterOptions)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(queryPlan)
+	if err != nil {
+		return fmt.Errorf("failed to marshal query plan: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Explain stats query successfully")
+	return nil
+}
+
+// authorize checks HTTP basic credentials against the configured admin account.
+// With no account configured every request is rejected.
+func (h *adminHandler) authorize(w http.ResponseWriter, r *http.Request) error {
+	username, password, ok := r.BasicAuth()
+	if !ok || h.credentials.Username == "" || h.credentials.Password == "" {
+		w.Header().Set("WWW-Authenticate", `Basic realm="stats-service admin"`)
+		return apperror.ErrUnauthorized
+	}
+
+	usernameMatch := subtle.ConstantTimeCompare([]byte(username), []byte(h.credentials.Username)) == 1
+	passwordMatch := subtle.ConstantTimeCompare([]byte(password), []byte(h.credentials.Password)) == 1
+	if !usernameMatch || !passwordMatch {
+		w.Header().Set("WWW-Authenticate", `Basic realm="stats-service admin"`)
+		return apperror.ErrUnauthorized
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"stats-service/internal/apperror"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/utils"
+)
+
+const (
+	alertsURL = "/api/alerts"
+	alertURL  = "/api/alerts/:uuid"
+)
+
+type alertHandler struct {
+	service AlertService
+	logger  *logging.Logger
+}
+
+func NewAlertHandler(service AlertService, logger *logging.Logger) Handler {
+	return &alertHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *alertHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, alertsURL, apperror.Middleware(h.GetAlertRules))
+	router.HandlerFunc(http.MethodPost, alertsURL, apperror.Middleware(h.CreateAlertRule))
+	router.HandlerFunc(http.MethodGet, alertURL, apperror.Middleware(h.GetAlertRule))
+	router.HandlerFunc(http.MethodPut, alertURL, apperror.Middleware(h.UpdateAlertRule))
+	router.HandlerFunc(http.MethodDelete, alertURL, apperror.Middleware(h.DeleteAlertRule))
+}
+
+// GetAlertRules
+// @Summary 	Get alert rules
+// @Description Lists the alert rules defined by a user.
+// @Tags 		Alerts
+// @Produce 	json
+// @Param 		user_uuid query 	string false "User UUID, required without X-User-UUID"
+// @Param 		X-User-UUID header string false "Caller UUID, user_uuid must be the caller"
+// @Success 	200 	  {array}  entity.AlertRule "Alert rules of the user"
+// @Failure 	400 	  {object} apperror.AppError "Missing user_uuid"
+// @Failure 	403 	  {object} apperror.AppError "user_uuid is not the caller"
+// @Failure 	418 	  {object} apperror.AppError "Something wrong with application logic"
+// @Router /alerts [get]
+func (h *alertHandler) GetAlertRules(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get alert rules")
+	w.Header().Set("Content-Type", "application/json")
+
+	userUUID, err := ownerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	rules, err := h.service.GetAll(r.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(rules)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert rules: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get alert rules successfully")
+	return nil
+}
+
+// CreateAlertRule
+// @Summary 	Create alert rule
+// @Description Defines an alert rule. threshold rules fire when spending in the period reaches the threshold, budget_percent rules when spending reaches the threshold percent of a budget, growth rules when spending so far is the threshold percent higher than in the same part of the previous period. Notifications are sent once per period as signed webhooks.
+// @Tags 		Alerts
+// @Accept 		json
+// @Produce 	json
+// @Param 		rule body 	entity.AlertRuleDTO true "Alert rule"
+// @Success 	201    {object} entity.AlertRule "Created alert rule"
+// @Failure 	400    {object} apperror.AppError "Validation error"
+// @Failure 	403    {object} apperror.AppError "user_uuid is not the caller"
+// @Failure 	418    {object} apperror.AppError "Something wrong with application logic"
+// @Router /alerts [post]
+func (h *alertHandler) CreateAlertRule(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Create alert rule")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto entity.AlertRuleDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON body")
+	}
+
+	userUUID, err := dtoOwner(r, dto.UserUUID)
+	if err != nil {
+		return err
+	}
+	dto.UserUUID = userUUID
+
+	rule, err := h.service.Create(r.Context(), dto)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert rule: %w", err)
+	}
+
+	w.Header().Set("Location", alertsURL+"/"+rule.UUID)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Create alert rule successfully")
+	return nil
+}
+
+// GetAlertRule
+// @Summary 	Get alert rule
+// @Tags 		Alerts
+// @Produce 	json
+// @Param 		uuid path 	  string true "Alert rule UUID"
+// @Param 		user_uuid query 	string false "Owner UUID, required without X-User-UUID"
+// @Param 		X-User-UUID header string false "Caller UUID, must own the alert rule"
+// @Success 	200  {object} entity.AlertRule "Alert rule"
+// @Failure 	403  {object} apperror.AppError "Alert rule belongs to another user"
+// @Failure 	404  {object} apperror.AppError "Alert rule not found"
+// @Failure 	418  {object} apperror.AppError "Something wrong with application logic"
+// @Router /alerts/{uuid} [get]
+func (h *alertHandler) GetAlertRule(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get alert rule")
+	w.Header().Set("Content-Type", "application/json")
+
+	userUUID, err := ownerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	uuid := httprouter.ParamsFromContext(r.Context()).ByName("uuid")
+	rule, err := h.service.GetOne(r.Context(), userUUID, uuid)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert rule: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get alert rule successfully")
+	return nil
+}
+
+// UpdateAlertRule
+// @Summary 	Update alert rule
+// @Description Replaces the definition of an alert rule and resets its state.
+// @Tags 		Alerts
+// @Accept 		json
+// @Produce 	json
+// @Param 		uuid   path 	string 			 true "Alert rule UUID"
+// @Param 		rule body 	entity.AlertRuleDTO true "Alert rule"
+// @Success 	200    {object} entity.AlertRule "Updated alert rule"
+// @Failure 	400    {object} apperror.AppError "Validation error"
+// @Failure 	403    {object} apperror.AppError "Alert rule belongs to another user"
+// @Failure 	404    {object} apperror.AppError "Alert rule not found"
+// @Failure 	418    {object} apperror.AppError "Something wrong with application logic"
+// @Router /alerts/{uuid} [put]
+func (h *alertHandler) UpdateAlertRule(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Update alert rule")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto entity.AlertRuleDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON body")
+	}
+
+	userUUID, err := dtoOwner(r, dto.UserUUID)
+	if err != nil {
+		return err
+	}
+	dto.UserUUID = userUUID
+
+	uuid := httprouter.ParamsFromContext(r.Context()).ByName("uuid")
+	rule, err := h.service.Update(r.Context(), uuid, dto)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal alert rule: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Update alert rule successfully")
+	return nil
+}
+
+// DeleteAlertRule
+// @Summary 	Delete alert rule
+// @Tags 		Alerts
+// @Param 		uuid path 	  string true "Alert rule UUID"
+// @Param 		user_uuid query 	string false "Owner UUID, required without X-User-UUID"
+// @Param 		X-User-UUID header string false "Caller UUID, must own the alert rule"
+// @Success 	204  "Alert rule deleted"
+// @Failure 	403  {object} apperror.AppError "Alert rule belongs to another user"
+// @Failure 	404  {object} apperror.AppError "Alert rule not found"
+// @Failure 	418  {object} apperror.AppError "Something wrong with application logic"
+// @Router /alerts/{uuid} [delete]
+func (h *alertHandler) DeleteAlertRule(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Delete alert rule")
+
+	userUUID, err := ownerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	uuid := httprouter.ParamsFromContext(r.Context()).ByName("uuid")
+	if err = h.service.Delete(r.Context(), userUUID, uuid); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("Delete alert rule successfully")
+	return nil
+}
@@ -0,0 +1,621 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"stats-service/internal/apperror"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/api/filter"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/utils"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	recurringURL = "/api/stats/recurring"
+	anomaliesURL = "/api/stats/anomalies"
+	forecastURL  = "/api/stats/forecast"
+	compareURL   = "/api/stats/compare"
+	balanceURL   = "/api/stats/balance"
+	histogramURL = "/api/stats/histogram"
+	topURL       = "/api/stats/top"
+	heatmapURL   = "/api/stats/heatmap"
+
+	defaultAnomalyWindowDays = 90
+	defaultAnomalyThreshold  = 3.5
+	defaultForecastHorizon   = 90
+	maxForecastHorizon       = 730
+	defaultHistogramBuckets  = 10
+	maxHistogramBuckets      = 100
+)
+
+// GetRecurring
+// @Summary 	Get recurring operations
+// @Description Detects recurring series such as subscriptions and rent among the operations of a user: operations of one category with a similar description and amount repeating weekly, monthly or yearly.
+// @Tags 		Analytics
+// @Produce 	json
+// @Param 		user_uuid 	  query    string false  "User UUID (required unless household_uuid is given)"
+// @Param 		household_uuid query    string false  "Household UUID, reports on all members or on the member given in user_uuid"
+// @Param 		X-User-UUID  header   string false  "Caller UUID, required with household_uuid; user_uuid must be the caller without it"
+// @Param 		category_name query    string false  "Category name (supports operators: substr)"
+// @Param 		type	 	  query    string false  "Category type"
+// @Param 		category_id   query    string false  "Category ID"
+// @Param 		description   query    string false  "Description (supports operators: substr)"
+// @Param 		money_sum 	  query    string false  "Money sum (supports operators: eq, neq, lt, lte, gt, gte, between)"
+// @Param 		date_time     query    string false  "Date and time of operation (supports operators: eq, between; format: yyyy-mm-dd)"
+// @Success 	200 		  {object} entity.RecurringReport "Recurring series, most confident first"
+// @Failure 	400 		  {object} apperror.AppError "Validation error in filter parameters"
+// @Failure 	401 		  {object} apperror.AppError "Missing caller for household_uuid"
+// @Failure 	403 		  {object} apperror.AppError "Caller is not a member of the household or not the user"
+// @Failure 	418 		  {object} apperror.AppError "Something wrong with application logic"
+// @Router /stats/recurring [get]
+func (h *handler) GetRecurring(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get recurring operations")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := requiredUserUUID(r); err != nil {
+		return err
+	}
+	filterOptions, err := parseFilterOptions(r)
+	if err != nil {
+		return err
+	}
+
+	report, err := h.service.GetRecurring(r.Context(), filterOptions)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal recurring operations: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get recurring operations successfully")
+	return nil
+}
+
+// GetAnomalies
+// @Summary 	Get spending anomalies
+// @Description Flags operations whose amount is unusual for their category, and days and months whose total deviates from the user's baseline. Every value is compared with the median and median absolute deviation of the values in the trailing window before it (robust z-score). Only expenses are analysed unless type is given.
+// @Tags 		Analytics
+// @Produce 	json
+// @Param 		user_uuid 	  query    string false  "User UUID (required unless household_uuid is given)"
+// @Param 		household_uuid query    string false  "Household UUID, reports on all members or on the member given in user_uuid"
+// @Param 		X-User-UUID  header   string false  "Caller UUID, required with household_uuid; user_uuid must be the caller without it"
+// @Param 		window 	 	  query    string false  "Trailing window the baseline is taken from (format: 90d, default: 90d)"
+// @Param 		threshold 	  query    number false  "Absolute robust z-score from which a value is reported (default: 3.5)"
+// @Param 		category_name query    string false  "Category name (supports operators: substr)"
+// @Param 		type	 	  query    string false  "Category type (default: Expense)"
+// @Param 		category_id   query    string false  "Category ID"
+// @Param 		description   query    string false  "Description (supports operators: substr)"
+// @Param 		money_sum 	  query    string false  "Money sum (supports operators: eq, neq, lt, lte, gt, gte, between)"
+// @Param 		date_time     query    string false  "Date and time of operation (supports operators: eq, between; format: yyyy-mm-dd)"
+// @Success 	200 		  {object} entity.AnomalyReport "Anomalous operations, days and months"
+// @Failure 	400 		  {object} apperror.AppError "Validation error in parameters"
+// @Failure 	401 		  {object} apperror.AppError "Missing caller for household_uuid"
+// @Failure 	403 		  {object} apperror.AppError "Caller is not a member of the household or not the user"
+// @Failure 	418 		  {object} apperror.AppError "Something wrong with application logic"
+// @Router /stats/anomalies [get]
+func (h *handler) GetAnomalies(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get anomalies")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := requiredUserUUID(r); err != nil {
+		return err
+	}
+	filterOptions, err := parseExpenseFilterOptions(r)
+	if err != nil {
+		return err
+	}
+
+	options := entity.AnomalyOptions{Threshold: defaultAnomalyThreshold}
+	if options.WindowDays, err = parseDaysParam(r, "window", defaultAnomalyWindowDays); err != nil {
+		return err
+	}
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		options.Threshold, err = strconv.ParseFloat(value, 64)
+		if err != nil || options.Threshold <= 0 {
+			return paramError("threshold", "must be a positive number")
+		}
+	}
+
+	report, err := h.service.GetAnomalies(r.Context(), filterOptions, options)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal anomalies: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get anomalies successfully")
+	return nil
+}
+
+// GetForecast
+// @Summary 	Get cash-flow forecast
+// @Description Projects daily income, expense, net and cumulative net for the horizon starting tomorrow, with lower and upper bounds of an 80% interval. Detected recurring operations are projected at their expected dates, everything else from historical averages per weekday and month of year. Filters narrow the history the forecast is based on, e.g. to a single category.
+// @Tags 		Analytics
+// @Produce 	json
+// @Param 		user_uuid 	  query    string false  "User UUID (required unless household_uuid is given)"
+// @Param 		household_uuid query    string false  "Household UUID, reports on all members or on the member given in user_uuid"
+// @Param 		X-User-UUID  header   string false  "Caller UUID, required with household_uuid; user_uuid must be the caller without it"
+// @Param 		horizon 	  query    string false  "Number of days to forecast (format: 90d, default: 90d, max: 730d)"
+// @Param 		category_name query    string false  "Category name (supports operators: substr)"
+// @Param 		type	 	  query    string false  "Category type"
+// @Param 		category_id   query    string false  "Category ID"
+// @Param 		description   query    string false  "Description (supports operators: substr)"
+// @Param 		money_sum 	  query    string false  "Money sum (supports operators: eq, neq, lt, lte, gt, gte, between)"
+// @Param 		date_time     query    string false  "Date and time of operation (supports operators: eq, between; format: yyyy-mm-dd)"
+// @Success 	200 		  {object} entity.ForecastReport "Daily forecast"
+// @Failure 	400 		  {object} apperror.AppError "Validation error in parameters"
+// @Failure 	401 		  {object} apperror.AppError "Missing caller for household_uuid"
+// @Failure 	403 		  {object} apperror.AppError "Caller is not a member of the household or not the user"
+// @Failure 	418 		  {object} apperror.AppError "Something wrong with application logic"
+// @Router /stats/forecast [get]
+func (h *handler) GetForecast(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get forecast")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := requiredUserUUID(r); err != nil {
+		return err
+	}
+	filterOptions, err := parseFilterOptions(r)
+	if err != nil {
+		return err
+	}
+
+	horizon, err := parseDaysParam(r, "horizon", defaultForecastHorizon)
+	if err != nil {
+		return err
+	}
+	if horizon > maxForecastHorizon {
+		return paramError("horizon", fmt.Sprintf("must not exceed %dd", maxForecastHorizon))
+	}
+
+	report, err := h.service.GetForecast(r.Context(), filterOptions, horizon)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal forecast: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get forecast successfully")
+	return nil
+}
+
+// GetComparison
+// @Summary 	Compare two periods
+// @Description Returns per-category, income, expense and total values for two date ranges with absolute and percentage deltas, and the categories that contributed most to the change. The second range is given explicitly with compare_from and compare_to, or derived from the first one with compare.
+// @Tags 		Analytics
+// @Produce 	json
+// @Param 		from 	 	  query    string true   "Start of the current range (format: yyyy-mm-dd)"
+// @Param 		to 	 		  query    string true   "End of the current range, inclusive (format: yyyy-mm-dd)"
+// @Param 		compare 	  query    string false  "How to derive the range to compare with (previous_period, same_period_last_year; default: previous_period)"
+// @Param 		compare_from  query    string false  "Start of the range to compare with (format: yyyy-mm-dd)"
+// @Param 		compare_to 	  query    string false  "End of the range to compare with, inclusive (format: yyyy-mm-dd)"
+// @Param 		user_uuid 	  query    string false  "User UUID (required unless household_uuid is given)"
+// @Param 		household_uuid query    string false  "Household UUID, reports on all members or on the member given in user_uuid"
+// @Param 		X-User-UUID  header   string false  "Caller UUID, required with household_uuid; user_uuid must be the caller without it"
+// @Param 		category_name query    string false  "Category name (supports operators: substr)"
+// @Param 		type	 	  query    string false  "Category type"
+// @Param 		category_id   query    string false  "Category ID"
+// @Param 		description   query    string false  "Description (supports operators: substr)"
+// @Param 		money_sum 	  query    string false  "Money sum (supports operators: eq, neq, lt, lte, gt, gte, between)"
+// @Success 	200 		  {object} entity.ComparisonReport "Comparison of the two periods"
+// @Failure 	400 		  {object} apperror.AppError "Validation error in parameters"
+// @Failure 	401 		  {object} apperror.AppError "Missing caller for household_uuid"
+// @Failure 	403 		  {object} apperror.AppError "Caller is not a member of the household or not the user"
+// @Failure 	418 		  {object} apperror.AppError "Something wrong with application logic"
+// @Router /stats/compare [get]
+func (h *handler) GetComparison(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get comparison")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := requiredUserUUID(r); err != nil {
+		return err
+	}
+	if r.URL.Query().Get(entity.DateTime) != "" {
+		return paramError(entity.DateTime, "periods are given with from and to")
+	}
+	filterOptions, err := parseFilterOptions(r)
+	if err != nil {
+		return err
+	}
+
+	current, err := parseDateRange(r, "from", "to")
+	if err != nil {
+		return err
+	}
+
+	var previous entity.DateRange
+	if r.URL.Query().Get("compare_from") != "" || r.URL.Query().Get("compare_to") != "" {
+		if previous, err = parseDateRange(r, "compare_from", "compare_to"); err != nil {
+			return err
+		}
+	} else {
+		switch r.URL.Query().Get("compare") {
+		case "", entity.ComparePreviousPeriod:
+			previous.To = current.From.AddDate(0, 0, -1)
+			previous.From = current.From.AddDate(0, 0, -current.Days())
+		case entity.CompareSamePeriodLastYear:
+			previous.From = current.From.AddDate(-1, 0, 0)
+			previous.To = current.To.AddDate(-1, 0, 0)
+		default:
+			return paramError("compare", fmt.Sprintf("possible values: %s, %s",
+				entity.ComparePreviousPeriod, entity.CompareSamePeriodLastYear))
+		}
+	}
+
+	report, err := h.service.GetComparison(r.Context(), filterOptions, current, previous)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal comparison: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get comparison successfully")
+	return nil
+}
+
+// GetBalance
+// @Summary 	Get running balance
+// @Description Computes the balance (income minus expense) of the matching operations over time, sampled at the end of every day, week or month from the first to the last operation. The opening balance is added to every point.
+// @Tags 		Analytics
+// @Produce 	json
+// @Param 		user_uuid 	  	query    string false  "User UUID (required unless household_uuid is given)"
+// @Param 		household_uuid query    string false  "Household UUID, reports on all members or on the member given in user_uuid"
+// @Param 		X-User-UUID   header   string false  "Caller UUID, required with household_uuid; user_uuid must be the caller without it"
+// @Param 		interval 	  	query    string false  "Sampling interval (day, week or month, default: day)"
+// @Param 		opening_balance query    number false  "Balance before the first operation (default: 0)"
+// @Param 		category_name 	query    string false  "Category name (supports operators: substr)"
+// @Param 		type	 	  	query    string false  "Category type"
+// @Param 		category_id   	query    string false  "Category ID"
+// @Param 		description   	query    string false  "Description (supports operators: substr)"
+// @Param 		money_sum 	  	query    string false  "Money sum (supports operators: eq, neq, lt, lte, gt, gte, between)"
+// @Param 		date_time     	query    string false  "Date and time of operation (supports operators: eq, between; format: yyyy-mm-dd)"
+// @Success 	200 		  	{object} entity.BalanceReport "Running balance"
+// @Failure 	400 		  	{object} apperror.AppError "Validation error in parameters"
+// @Failure 	401 		  	{object} apperror.AppError "Missing caller for household_uuid"
+// @Failure 	403 		  	{object} apperror.AppError "Caller is not a member of the household or not the user"
+// @Failure 	418 		  	{object} apperror.AppError "Something wrong with application logic"
+// @Router /stats/balance [get]
+func (h *handler) GetBalance(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get balance")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := requiredUserUUID(r); err != nil {
+		return err
+	}
+	filterOptions, err := parseFilterOptions(r)
+	if err != nil {
+		return err
+	}
+
+	interval, err := parseIntervalParam(r)
+	if err != nil {
+		return err
+	}
+
+	openingBalance := 0.0
+	if value := r.URL.Query().Get("opening_balance"); value != "" {
+		openingBalance, err = strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(openingBalance) || math.IsInf(openingBalance, 0) {
+			return paramError("opening_balance", "must be a number")
+		}
+	}
+
+	report, err := h.service.GetBalance(r.Context(), filterOptions, interval, openingBalance)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal balance: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get balance successfully")
+	return nil
+}
+
+// GetHistogram
+// @Summary 	Get histogram of amounts
+// @Description Counts and sums the matching operations in buckets spanning the range of their money sums, together with the distribution of the amounts. Logarithmic buckets grow by a constant ratio and require positive amounts.
+// @Tags 		Analytics
+// @Produce 	json
+// @Param 		user_uuid 	  query    string false  "User UUID (required unless household_uuid is given)"
+// @Param 		household_uuid query    string false  "Household UUID, reports on all members or on the member given in user_uuid"
+// @Param 		X-User-UUID  header   string false  "Caller UUID, required with household_uuid; user_uuid must be the caller without it"
+// @Param 		buckets 	  query    int 	  false  "Number of buckets (default: 10, max: 100)"
+// @Param 		scale 	  	  query    string false  "Bucket scale (linear or log, default: linear)"
+// @Param 		category_name query    string false  "Category name (supports operators: substr)"
+// @Param 		type	 	  query    string false  "Category type"
+// @Param 		category_id   query    string false  "Category ID"
+// @Param 		description   query    string false  "Description (supports operators: substr)"
+// @Param 		money_sum 	  query    string false  "Money sum (supports operators: eq, neq, lt, lte, gt, gte, between)"
+// @Param 		date_time     query    string false  "Date and time of operation (supports operators: eq, between; format: yyyy-mm-dd)"
+// @Success 	200 		  {object} entity.Histogram "Histogram of amounts"
+// @Failure 	400 		  {object} apperror.AppError "Validation error in parameters"
+// @Failure 	401 		  {object} apperror.AppError "Missing caller for household_uuid"
+// @Failure 	403 		  {object} apperror.AppError "Caller is not a member of the household or not the user"
+// @Failure 	418 		  {object} apperror.AppError "Something wrong with application logic"
+// @Router /stats/histogram [get]
+func (h *handler) GetHistogram(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get histogram")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := requiredUserUUID(r); err != nil {
+		return err
+	}
+	filterOptions, err := parseFilterOptions(r)
+	if err != nil {
+		return err
+	}
+
+	buckets := defaultHistogramBuckets
+	if value := r.URL.Query().Get("buckets"); value != "" {
+		buckets, err = strconv.Atoi(value)
+		if err != nil || buckets <= 0 || buckets > maxHistogramBuckets {
+			return paramError("buckets", fmt.Sprintf("must be a number between 1 and %d", maxHistogramBuckets))
+		}
+	}
+
+	scale := r.URL.Query().Get("scale")
+	switch scale {
+	case "":
+		scale = entity.HistogramLinear
+	case entity.HistogramLinear, entity.HistogramLog:
+	default:
+		return paramError("scale", "must be one of: linear, log")
+	}
+
+	histogram, err := h.service.GetHistogram(r.Context(), filterOptions, buckets, scale)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(histogram)
+	if err != nil {
+		return fmt.Errorf("failed to marshal histogram: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get histogram successfully")
+	return nil
+}
+
+// GetTop
+// @Summary 	Get top spending
+// @Description Ranks categories, normalized descriptions (merchants) or single operations by total or by number of operations. Everything beyond the limit is aggregated into the other bucket, so items and other add up to the totals. Only expenses are ranked unless type is given.
+// @Tags 		Analytics
+// @Produce 	json
+// @Param 		user_uuid 	  query    string false  "User UUID (required unless household_uuid is given)"
+// @Param 		household_uuid query    string false  "Household UUID, reports on all members or on the member given in user_uuid"
+// @Param 		X-User-UUID  header   string false  "Caller UUID, required with household_uuid; user_uuid must be the caller without it"
+// @Param 		by 	  	  	  query    string false  "What to rank (category, description or operation, default: category)"
+// @Param 		metric 	  	  query    string false  "Ranking metric (sum or count, default: sum; operations support sum only)"
+// @Param 		limit 	  	  query    int 	  false  "Number of items"
+// @Param 		category_name query    string false  "Category name (supports operators: substr)"
+// @Param 		type	 	  query    string false  "Category type (default: Expense)"
+// @Param 		category_id   query    string false  "Category ID"
+// @Param 		description   query    string false  "Description (supports operators: substr)"
+// @Param 		money_sum 	  query    string false  "Money sum (supports operators: eq, neq, lt, lte, gt, gte, between)"
+// @Param 		date_time     query    string false  "Date and time of operation (supports operators: eq, between; format: yyyy-mm-dd)"
+// @Success 	200 		  {object} entity.TopReport "Top items and the other bucket"
+// @Failure 	400 		  {object} apperror.AppError "Validation error in parameters"
+// @Failure 	401 		  {object} apperror.AppError "Missing caller for household_uuid"
+// @Failure 	403 		  {object} apperror.AppError "Caller is not a member of the household or not the user"
+// @Failure 	418 		  {object} apperror.AppError "Something wrong with application logic"
+// @Router /stats/top [get]
+func (h *handler) GetTop(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get top")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := requiredUserUUID(r); err != nil {
+		return err
+	}
+	filterOptions, err := parseExpenseFilterOptions(r)
+	if err != nil {
+		return err
+	}
+	if filterOptions.Limit() < 1 {
+		return paramError("limit", "must be a positive number")
+	}
+
+	by := r.URL.Query().Get("by")
+	switch by {
+	case "":
+		by = entity.TopByCategory
+	case entity.TopByCategory, entity.TopByDescription, entity.TopByOperation:
+	default:
+		return paramError("by", "must be one of: category, description, operation")
+	}
+
+	metric := r.URL.Query().Get("metric")
+	switch metric {
+	case "":
+		metric = entity.TopMetricSum
+	case entity.TopMetricSum, entity.TopMetricCount:
+	default:
+		return paramError("metric", "must be one of: sum, count")
+	}
+	if by == entity.TopByOperation && metric == entity.TopMetricCount {
+		return paramError("metric", "operations can only be ranked by sum")
+	}
+
+	report, err := h.service.GetTop(r.Context(), filterOptions, by, metric)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal top report: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get top successfully")
+	return nil
+}
+
+// GetHeatmap
+// @Summary 	Get weekday and hour heatmap
+// @Description Counts and sums the matching operations by day of week (0 is Sunday) and hour of the day in the given timezone. Only expenses are included unless type is given.
+// @Tags 		Analytics
+// @Produce 	json
+// @Param 		user_uuid 	  query    string false  "User UUID (required unless household_uuid is given)"
+// @Param 		household_uuid query    string false  "Household UUID, reports on all members or on the member given in user_uuid"
+// @Param 		X-User-UUID  header   string false  "Caller UUID, required with household_uuid; user_uuid must be the caller without it"
+// @Param 		timezone 	  query    string false  "IANA timezone of the user, e.g. Europe/Berlin (default: UTC)"
+// @Param 		category_name query    string false  "Category name (supports operators: substr)"
+// @Param 		type	 	  query    string false  "Category type (default: Expense)"
+// @Param 		category_id   query    string false  "Category ID"
+// @Param 		description   query    string false  "Description (supports operators: substr)"
+// @Param 		money_sum 	  query    string false  "Money sum (supports operators: eq, neq, lt, lte, gt, gte, between)"
+// @Param 		date_time     query    string false  "Date and time of operation (supports operators: eq, between; format: yyyy-mm-dd)"
+// @Success 	200 		  {object} entity.Heatmap "7x24 matrices of counts and sums"
+// @Failure 	400 		  {object} apperror.AppError "Validation error in parameters"
+// @Failure 	401 		  {object} apperror.AppError "Missing caller for household_uuid"
+// @Failure 	403 		  {object} apperror.AppError "Caller is not a member of the household or not the user"
+// @Failure 	418 		  {object} apperror.AppError "Something wrong with application logic"
+// @Router /stats/heatmap [get]
+func (h *handler) GetHeatmap(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get heatmap")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	if _, err := requiredUserUUID(r); err != nil {
+		return err
+	}
+	filterOptions, err := parseExpenseFilterOptions(r)
+	if err != nil {
+		return err
+	}
+
+	timezone := r.URL.Query().Get("timezone")
+	if timezone == "" {
+		timezone = time.UTC.String()
+	}
+	// Local is the zone of the server, which the database does not know
+	if _, err = time.LoadLocation(timezone); err != nil || timezone == "Local" {
+		return paramError("timezone", "must be an IANA timezone name, e.g. Europe/Berlin")
+	}
+
+	heatmap, err := h.service.GetHeatmap(r.Context(), filterOptions, timezone)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(heatmap)
+	if err != nil {
+		return fmt.Errorf("failed to marshal heatmap: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get heatmap successfully")
+	return nil
+}
+
+func parseIntervalParam(r *http.Request) (string, error) {
+	switch value := r.URL.Query().Get("interval"); value {
+	case "":
+		return entity.IntervalDay, nil
+	case entity.IntervalDay, entity.IntervalWeek, entity.IntervalMonth:
+		return value, nil
+	default:
+		return "", paramError("interval", "must be one of: day, week, month")
+	}
+}
+
+// parseDateRange parses a required inclusive range of dates from two query parameters.
+func parseDateRange(r *http.Request, fromParam, toParam string) (entity.DateRange, error) {
+	var dateRange entity.DateRange
+	var err error
+	if dateRange.From, err = time.Parse(entity.DateLayout, r.URL.Query().Get(fromParam)); err != nil {
+		return dateRange, paramError(fromParam, "must be a date in yyyy-mm-dd format")
+	}
+	if dateRange.To, err = time.Parse(entity.DateLayout, r.URL.Query().Get(toParam)); err != nil {
+		return dateRange, paramError(toParam, "must be a date in yyyy-mm-dd format")
+	}
+	if dateRange.To.Before(dateRange.From) {
+		return dateRange, paramError(toParam, fmt.Sprintf("must not be before %s", fromParam))
+	}
+	return dateRange, nil
+}
+
+// parseExpenseFilterOptions parses the filter parameters and limits the operations
+// to expenses unless a category type is given.
+func parseExpenseFilterOptions(r *http.Request) (filter.Options, error) {
+	filterOptions, err := parseFilterOptions(r)
+	if err != nil {
+		return nil, err
+	}
+	if r.URL.Query().Get(entity.TypeOfCategory) == "" {
+		err = filterOptions.AddField(entity.TypeOfCategory, filter.OperatorEqual,
+			[]string{string(entity.ExpenseType)}, filter.DataTypeString)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return filterOptions, nil
+}
+
+// parseDaysParam parses a number of days given as "90d" or "90".
+func parseDaysParam(r *http.Request, name string, defaultDays int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return defaultDays, nil
+	}
+	days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+	if err != nil || days <= 0 {
+		return 0, paramError(name, "must be a positive number of days, e.g. 90d")
+	}
+	return days, nil
+}
+
+func paramError(name, message string) error {
+	validationErr := apperror.BadRequestError("params validation failed")
+	validationErr.WithParams(map[string]string{name: message})
+	return validationErr
+}
@@ -0,0 +1,331 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"stats-service/internal/apperror"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/utils"
+	"strings"
+	"time"
+)
+
+const (
+	budgetsURL      = "/api/budgets"
+	budgetURL       = "/api/budgets/:uuid"
+	budgetReportURL = "/api/stats/budgets"
+)
+
+type budgetHandler struct {
+	service    BudgetService
+	households HouseholdService
+	logger     *logging.Logger
+}
+
+func NewBudgetHandler(service BudgetService, households HouseholdService, logger *logging.Logger) Handler {
+	return &budgetHandler{
+		service:    service,
+		households: households,
+		logger:     logger,
+	}
+}
+
+func (h *budgetHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, budgetsURL, apperror.Middleware(h.GetBudgets))
+	router.HandlerFunc(http.MethodPost, budgetsURL, apperror.Middleware(h.CreateBudget))
+	router.HandlerFunc(http.MethodGet, budgetURL, apperror.Middleware(h.GetBudget))
+	router.HandlerFunc(http.MethodPut, budgetURL, apperror.Middleware(h.UpdateBudget))
+	router.HandlerFunc(http.MethodDelete, budgetURL, apperror.Middleware(h.DeleteBudget))
+	router.HandlerFunc(http.MethodGet, budgetReportURL, apperror.Middleware(scopeToHousehold(h.households, h.GetBudgetReport)))
+}
+
+// GetBudgets
+// @Summary 	Get budgets
+// @Description Lists the budgets defined by a user.
+// @Tags 		Budgets
+// @Produce 	json
+// @Param 		user_uuid query 	string false "User UUID, required without X-User-UUID"
+// @Param 		X-User-UUID header string false "Caller UUID, user_uuid must be the caller"
+// @Success 	200 	  {array}  entity.Budget "Budgets of the user"
+// @Failure 	400 	  {object} apperror.AppError "Missing user_uuid"
+// @Failure 	403 	  {object} apperror.AppError "user_uuid is not the caller"
+// @Failure 	418 	  {object} apperror.AppError "Something wrong with application logic"
+// @Router /budgets [get]
+func (h *budgetHandler) GetBudgets(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get budgets")
+	w.Header().Set("Content-Type", "application/json")
+
+	userUUID, err := ownerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	budgets, err := h.service.GetAll(r.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(budgets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal budgets: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get budgets successfully")
+	return nil
+}
+
+// CreateBudget
+// @Summary 	Create budget
+// @Description Defines a budget for a category or for all categories of a type. Custom periods require start_date and end_date.
+// @Tags 		Budgets
+// @Accept 		json
+// @Produce 	json
+// @Param 		budget body 	entity.BudgetDTO true "Budget"
+// @Success 	201    {object} entity.Budget "Created budget"
+// @Failure 	400    {object} apperror.AppError "Validation error"
+// @Failure 	403    {object} apperror.AppError "user_uuid is not the caller"
+// @Failure 	418    {object} apperror.AppError "Something wrong with application logic"
+// @Router /budgets [post]
+func (h *budgetHandler) CreateBudget(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Create budget")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto entity.BudgetDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON body")
+	}
+
+	userUUID, err := dtoOwner(r, dto.UserUUID)
+	if err != nil {
+		return err
+	}
+	dto.UserUUID = userUUID
+
+	budget, err := h.service.Create(r.Context(), dto)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(budget)
+	if err != nil {
+		return fmt.Errorf("failed to marshal budget: %w", err)
+	}
+
+	w.Header().Set("Location", budgetsURL+"/"+budget.UUID)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Create budget successfully")
+	return nil
+}
+
+// GetBudget
+// @Summary 	Get budget
+// @Tags 		Budgets
+// @Produce 	json
+// @Param 		uuid path 	  string true "Budget UUID"
+// @Param 		user_uuid query 	string false "Owner UUID, required without X-User-UUID"
+// @Param 		X-User-UUID header string false "Caller UUID, must own the budget"
+// @Success 	200  {object} entity.Budget "Budget"
+// @Failure 	403  {object} apperror.AppError "Budget belongs to another user"
+// @Failure 	404  {object} apperror.AppError "Budget not found"
+// @Failure 	418  {object} apperror.AppError "Something wrong with application logic"
+// @Router /budgets/{uuid} [get]
+func (h *budgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get budget")
+	w.Header().Set("Content-Type", "application/json")
+
+	userUUID, err := ownerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	uuid := httprouter.ParamsFromContext(r.Context()).ByName("uuid")
+	budget, err := h.service.GetOne(r.Context(), userUUID, uuid)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(budget)
+	if err != nil {
+		return fmt.Errorf("failed to marshal budget: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get budget successfully")
+	return nil
+}
+
+// UpdateBudget
+// @Summary 	Update budget
+// @Description Replaces the definition of a budget.
+// @Tags 		Budgets
+// @Accept 		json
+// @Produce 	json
+// @Param 		uuid   path 	string 			 true "Budget UUID"
+// @Param 		budget body 	entity.BudgetDTO true "Budget"
+// @Success 	200    {object} entity.Budget "Updated budget"
+// @Failure 	400    {object} apperror.AppError "Validation error"
+// @Failure 	403    {object} apperror.AppError "Budget belongs to another user"
+// @Failure 	404    {object} apperror.AppError "Budget not found"
+// @Failure 	418    {object} apperror.AppError "Something wrong with application logic"
+// @Router /budgets/{uuid} [put]
+func (h *budgetHandler) UpdateBudget(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Update budget")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto entity.BudgetDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON body")
+	}
+
+	userUUID, err := dtoOwner(r, dto.UserUUID)
+	if err != nil {
+		return err
+	}
+	dto.UserUUID = userUUID
+
+	uuid := httprouter.ParamsFromContext(r.Context()).ByName("uuid")
+	budget, err := h.service.Update(r.Context(), uuid, dto)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(budget)
+	if err != nil {
+		return fmt.Errorf("failed to marshal budget: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Update budget successfully")
+	return nil
+}
+
+// DeleteBudget
+// @Summary 	Delete budget
+// @Tags 		Budgets
+// @Param 		uuid path 	  string true "Budget UUID"
+// @Param 		user_uuid query 	string false "Owner UUID, required without X-User-UUID"
+// @Param 		X-User-UUID header string false "Caller UUID, must own the budget"
+// @Success 	204  "Budget deleted"
+// @Failure 	403  {object} apperror.AppError "Budget belongs to another user"
+// @Failure 	404  {object} apperror.AppError "Budget not found"
+// @Failure 	418  {object} apperror.AppError "Something wrong with application logic"
+// @Router /budgets/{uuid} [delete]
+func (h *budgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Delete budget")
+
+	userUUID, err := ownerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	uuid := httprouter.ParamsFromContext(r.Context()).ByName("uuid")
+	if err = h.service.Delete(r.Context(), userUUID, uuid); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("Delete budget successfully")
+	return nil
+}
+
+// GetBudgetReport
+// @Summary 	Get budget report
+// @Description Compares actual spending with every budget of a user or of the members of a household for the period containing the given date: remaining amount, percent used and the overrun projected by extrapolating spending to the end of the period.
+// @Tags 		Budgets
+// @Produce 	json
+// @Param 		user_uuid query 	string false "User UUID, required without household_uuid"
+// @Param 		household_uuid query string false "Household UUID, reports on all members or on the member given in user_uuid"
+// @Param 		X-User-UUID header string false "Caller UUID, required with household_uuid; user_uuid must be the caller without it"
+// @Param 		date 	  query 	string false "Reference date (format: yyyy-mm-dd, default: today)"
+// @Success 	200 	  {object} entity.BudgetReport "Budget vs actual report"
+// @Failure 	400 	  {object} apperror.AppError "Validation error in parameters"
+// @Failure 	401 	  {object} apperror.AppError "Missing caller for household_uuid"
+// @Failure 	403 	  {object} apperror.AppError "Caller is not a member of the household or not the user"
+// @Failure 	418 	  {object} apperror.AppError "Something wrong with application logic"
+// @Router /stats/budgets [get]
+func (h *budgetHandler) GetBudgetReport(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get budget report")
+	w.Header().Set("Content-Type", "application/json")
+
+	userUUIDs, err := requiredUserUUIDs(r)
+	if err != nil {
+		return err
+	}
+
+	date := time.Now()
+	if value := r.URL.Query().Get("date"); value != "" {
+		date, err = time.Parse(entity.DateLayout, value)
+		if err != nil {
+			return paramError("date", "must be a date in yyyy-mm-dd format")
+		}
+	}
+
+	report, err := h.service.GetReport(r.Context(), userUUIDs, date)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal budget report: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get budget report successfully")
+	return nil
+}
+
+func requiredUserUUID(r *http.Request) (string, error) {
+	userUUID := r.URL.Query().Get(entity.UserUUID)
+	if userUUID == "" {
+		return "", paramError(entity.UserUUID, "is required")
+	}
+	return userUUID, nil
+}
+
+// ownerUUID returns the user whose budgets, goals or alert rules are requested: the caller when
+// X-User-UUID is set, who may only name themselves in user_uuid, otherwise the required user_uuid.
+func ownerUUID(r *http.Request) (string, error) {
+	return dtoOwner(r, r.URL.Query().Get(entity.UserUUID))
+}
+
+// dtoOwner checks userUUID, the owner given in a request, against the caller. It defaults to the
+// caller and is required when there is none.
+func dtoOwner(r *http.Request, userUUID string) (string, error) {
+	if caller := r.Header.Get(HeaderUserUUID); caller != "" {
+		if userUUID != "" && userUUID != caller {
+			return "", apperror.ErrForbidden
+		}
+		return caller, nil
+	}
+	if userUUID == "" {
+		return "", paramError(entity.UserUUID, "is required")
+	}
+	return userUUID, nil
+}
+
+// requiredUserUUIDs returns the users a report scoped by scopeToHousehold covers.
+func requiredUserUUIDs(r *http.Request) ([]string, error) {
+	userUUID, err := requiredUserUUID(r)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(userUUID, ","), nil
+}
@@ -0,0 +1,292 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"stats-service/internal/apperror"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/utils"
+	"time"
+)
+
+const (
+	goalsURL      = "/api/goals"
+	goalURL       = "/api/goals/:uuid"
+	goalReportURL = "/api/stats/goals"
+)
+
+type goalHandler struct {
+	service    GoalService
+	households HouseholdService
+	logger     *logging.Logger
+}
+
+func NewGoalHandler(service GoalService, households HouseholdService, logger *logging.Logger) Handler {
+	return &goalHandler{
+		service:    service,
+		households: households,
+		logger:     logger,
+	}
+}
+
+func (h *goalHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, goalsURL, apperror.Middleware(h.GetGoals))
+	router.HandlerFunc(http.MethodPost, goalsURL, apperror.Middleware(h.CreateGoal))
+	router.HandlerFunc(http.MethodGet, goalURL, apperror.Middleware(h.GetGoal))
+	router.HandlerFunc(http.MethodPut, goalURL, apperror.Middleware(h.UpdateGoal))
+	router.HandlerFunc(http.MethodDelete, goalURL, apperror.Middleware(h.DeleteGoal))
+	router.HandlerFunc(http.MethodGet, goalReportURL, apperror.Middleware(scopeToHousehold(h.households, h.GetGoalReport)))
+}
+
+// GetGoals
+// @Summary 	Get goals
+// @Description Lists the savings goals of a user, nearest deadline first.
+// @Tags 		Goals
+// @Produce 	json
+// @Param 		user_uuid query 	string false "User UUID, required without X-User-UUID"
+// @Param 		X-User-UUID header string false "Caller UUID, user_uuid must be the caller"
+// @Success 	200 	  {array}  entity.Goal "Goals of the user"
+// @Failure 	400 	  {object} apperror.AppError "Missing user_uuid"
+// @Failure 	403 	  {object} apperror.AppError "user_uuid is not the caller"
+// @Failure 	418 	  {object} apperror.AppError "Something wrong with application logic"
+// @Router /goals [get]
+func (h *goalHandler) GetGoals(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get goals")
+	w.Header().Set("Content-Type", "application/json")
+
+	userUUID, err := ownerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	goals, err := h.service.GetAll(r.Context(), userUUID)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(goals)
+	if err != nil {
+		return fmt.Errorf("failed to marshal goals: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get goals successfully")
+	return nil
+}
+
+// CreateGoal
+// @Summary 	Create goal
+// @Description Defines a savings goal: a target amount to save by the deadline, counted either as net savings (income minus expense) or as income, optionally of a single category. The start date defaults to today.
+// @Tags 		Goals
+// @Accept 		json
+// @Produce 	json
+// @Param 		goal body 	entity.GoalDTO true "Goal"
+// @Success 	201    {object} entity.Goal "Created goal"
+// @Failure 	400    {object} apperror.AppError "Validation error"
+// @Failure 	403    {object} apperror.AppError "user_uuid is not the caller"
+// @Failure 	418    {object} apperror.AppError "Something wrong with application logic"
+// @Router /goals [post]
+func (h *goalHandler) CreateGoal(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Create goal")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto entity.GoalDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON body")
+	}
+
+	userUUID, err := dtoOwner(r, dto.UserUUID)
+	if err != nil {
+		return err
+	}
+	dto.UserUUID = userUUID
+
+	goal, err := h.service.Create(r.Context(), dto)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(goal)
+	if err != nil {
+		return fmt.Errorf("failed to marshal goal: %w", err)
+	}
+
+	w.Header().Set("Location", goalsURL+"/"+goal.UUID)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Create goal successfully")
+	return nil
+}
+
+// GetGoal
+// @Summary 	Get goal
+// @Tags 		Goals
+// @Produce 	json
+// @Param 		uuid path 	  string true "Goal UUID"
+// @Param 		user_uuid query 	string false "Owner UUID, required without X-User-UUID"
+// @Param 		X-User-UUID header string false "Caller UUID, must own the goal"
+// @Success 	200  {object} entity.Goal "Goal"
+// @Failure 	403  {object} apperror.AppError "Goal belongs to another user"
+// @Failure 	404  {object} apperror.AppError "Goal not found"
+// @Failure 	418  {object} apperror.AppError "Something wrong with application logic"
+// @Router /goals/{uuid} [get]
+func (h *goalHandler) GetGoal(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get goal")
+	w.Header().Set("Content-Type", "application/json")
+
+	userUUID, err := ownerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	uuid := httprouter.ParamsFromContext(r.Context()).ByName("uuid")
+	goal, err := h.service.GetOne(r.Context(), userUUID, uuid)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(goal)
+	if err != nil {
+		return fmt.Errorf("failed to marshal goal: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get goal successfully")
+	return nil
+}
+
+// UpdateGoal
+// @Summary 	Update goal
+// @Description Replaces the definition of a goal.
+// @Tags 		Goals
+// @Accept 		json
+// @Produce 	json
+// @Param 		uuid   path 	string 			 true "Goal UUID"
+// @Param 		goal body 	entity.GoalDTO true "Goal"
+// @Success 	200    {object} entity.Goal "Updated goal"
+// @Failure 	400    {object} apperror.AppError "Validation error"
+// @Failure 	403    {object} apperror.AppError "Goal belongs to another user"
+// @Failure 	404    {object} apperror.AppError "Goal not found"
+// @Failure 	418    {object} apperror.AppError "Something wrong with application logic"
+// @Router /goals/{uuid} [put]
+func (h *goalHandler) UpdateGoal(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Update goal")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	var dto entity.GoalDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON body")
+	}
+
+	userUUID, err := dtoOwner(r, dto.UserUUID)
+	if err != nil {
+		return err
+	}
+	dto.UserUUID = userUUID
+
+	uuid := httprouter.ParamsFromContext(r.Context()).ByName("uuid")
+	goal, err := h.service.Update(r.Context(), uuid, dto)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(goal)
+	if err != nil {
+		return fmt.Errorf("failed to marshal goal: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Update goal successfully")
+	return nil
+}
+
+// DeleteGoal
+// @Summary 	Delete goal
+// @Tags 		Goals
+// @Param 		uuid path 	  string true "Goal UUID"
+// @Param 		user_uuid query 	string false "Owner UUID, required without X-User-UUID"
+// @Param 		X-User-UUID header string false "Caller UUID, must own the goal"
+// @Success 	204  "Goal deleted"
+// @Failure 	403  {object} apperror.AppError "Goal belongs to another user"
+// @Failure 	404  {object} apperror.AppError "Goal not found"
+// @Failure 	418  {object} apperror.AppError "Something wrong with application logic"
+// @Router /goals/{uuid} [delete]
+func (h *goalHandler) DeleteGoal(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Delete goal")
+
+	userUUID, err := ownerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	uuid := httprouter.ParamsFromContext(r.Context()).ByName("uuid")
+	if err = h.service.Delete(r.Context(), userUUID, uuid); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("Delete goal successfully")
+	return nil
+}
+
+// GetGoalReport
+// @Summary 	Get goal progress
+// @Description Reports the progress of every goal of a user or of the members of a household as of the given date: amount saved since the start of the goal, the monthly contribution required to reach the target by the deadline and the completion date projected from the average monthly pace so far.
+// @Tags 		Goals
+// @Produce 	json
+// @Param 		user_uuid query 	string false "User UUID, required without household_uuid"
+// @Param 		household_uuid query string false "Household UUID, reports on all members or on the member given in user_uuid"
+// @Param 		X-User-UUID header string false "Caller UUID, required with household_uuid; user_uuid must be the caller without it"
+// @Param 		date 	  query 	string false "Reference date (format: yyyy-mm-dd, default: today)"
+// @Success 	200 	  {object} entity.GoalReport "Goal progress report"
+// @Failure 	400 	  {object} apperror.AppError "Validation error in parameters"
+// @Failure 	401 	  {object} apperror.AppError "Missing caller for household_uuid"
+// @Failure 	403 	  {object} apperror.AppError "Caller is not a member of the household or not the user"
+// @Failure 	418 	  {object} apperror.AppError "Something wrong with application logic"
+// @Router /stats/goals [get]
+func (h *goalHandler) GetGoalReport(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get goal report")
+	w.Header().Set("Content-Type", "application/json")
+
+	userUUIDs, err := requiredUserUUIDs(r)
+	if err != nil {
+		return err
+	}
+
+	date := time.Now()
+	if value := r.URL.Query().Get("date"); value != "" {
+		date, err = time.Parse(entity.DateLayout, value)
+		if err != nil {
+			return paramError("date", "must be a date in yyyy-mm-dd format")
+		}
+	}
+
+	report, err := h.service.GetReport(r.Context(), userUUIDs, date)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal goal report: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get goal report successfully")
+	return nil
+}
@@ -1,17 +1,21 @@
 package controller
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"github.com/julienschmidt/httprouter"
 	"net/http"
 	"stats-service/internal/apperror"
 	"stats-service/internal/domain/entity"
+	"stats-service/pkg/api/etag"
 	"stats-service/pkg/api/filter"
 	"stats-service/pkg/api/sort"
 	"stats-service/pkg/logging"
 	"stats-service/pkg/utils"
+	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -19,28 +23,47 @@ const (
 )
 
 type handler struct {
-	service Service
-	logger  *logging.Logger
+	service      Service
+	households   HouseholdService
+	defaultLimit func() int
+	logger       *logging.Logger
 }
 
-func NewHandler(service Service, logger *logging.Logger) Handler {
+func NewHandler(service Service, households HouseholdService, defaultLimit func() int, logger *logging.Logger) Handler {
 	return &handler{
-		service: service,
-		logger:  logger,
+		service:      service,
+		households:   households,
+		defaultLimit: defaultLimit,
+		logger:       logger,
 	}
 }
 
 func (h *handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodGet, operationsURL,
-		filter.Middleware(sort.Middleware(apperror.Middleware(h.GetOperations), entity.DateTime, sort.ASC), 20))
+		filter.Middleware(sort.Middleware(apperror.Middleware(h.scoped(h.GetOperations)), entity.DateTime, sort.ASC), h.defaultLimit))
+	router.HandlerFunc(http.MethodGet, recurringURL, filter.Middleware(apperror.Middleware(h.scoped(h.GetRecurring)), h.defaultLimit))
+	router.HandlerFunc(http.MethodGet, anomaliesURL, filter.Middleware(apperror.Middleware(h.scoped(h.GetAnomalies)), h.defaultLimit))
+	router.HandlerFunc(http.MethodGet, forecastURL, filter.Middleware(apperror.Middleware(h.scoped(h.GetForecast)), h.defaultLimit))
+	router.HandlerFunc(http.MethodGet, compareURL, filter.Middleware(apperror.Middleware(h.scoped(h.GetComparison)), h.defaultLimit))
+	router.HandlerFunc(http.MethodGet, balanceURL, filter.Middleware(apperror.Middleware(h.scoped(h.GetBalance)), h.defaultLimit))
+	router.HandlerFunc(http.MethodGet, histogramURL, filter.Middleware(apperror.Middleware(h.scoped(h.GetHistogram)), h.defaultLimit))
+	router.HandlerFunc(http.MethodGet, topURL, filter.Middleware(apperror.Middleware(h.scoped(h.GetTop)), h.defaultLimit))
+	router.HandlerFunc(http.MethodGet, heatmapURL, filter.Middleware(apperror.Middleware(h.scoped(h.GetHeatmap)), h.defaultLimit))
+}
+
+// scoped lets next report on a whole household, see scopeToHousehold.
+func (h *handler) scoped(next func(http.ResponseWriter, *http.Request) error) func(http.ResponseWriter, *http.Request) error {
+	return scopeToHousehold(h.households, next)
 }
 
 // GetOperations
 // @Summary 	Get operations
-// @Description Retrieves a list of operations with support for filtering and sorting.
+// @Description Retrieves a list of operations with support for filtering and sorting, together with the total and the distribution (mean, standard deviation, median and percentiles) of their money sums.
 // @Tags 		Operations
 // @Produce 	json
 // @Param 		user_uuid 	  path 	   string false  "User UUID"
+// @Param 		household_uuid path 	   string false  "Household UUID, reports on all members or on the member given in user_uuid"
+// @Param 		X-User-UUID  header   string false  "Caller UUID, required with household_uuid; user_uuid must be the caller without it"
 // @Param 		category_name path 	   string false  "Category name (supports operators: substr)"
 // @Param 		type	 	  path 	   string false  "Category type"
 // @Param 		category_id   path 	   string false  "Category ID"
@@ -49,14 +72,19 @@ func (h *handler) Register(router *httprouter.Router) {
 // @Param 		date_time     path 	   string false  "Date and time of operation (supports operators: eq, between; format: yyyy-mm-dd)"
 // @Param 		sort_by 	  path 	   string false  "Field to sort by (money_sum, date_time, description)"
 // @Param 		sort_order 	  path 	   string false  "Sort order (asc, desc)"
+// @Param 		If-None-Match header   string false  "ETag of a previously received report"
 // @Success 	200 		  {object} entity.Report "List of operations"
+// @Success 	304 		  "Report has not changed since the given ETag"
 // @Failure 	400 		  {object} apperror.AppError "Validation error in filter or sort parameters"
+// @Failure 	401 		  {object} apperror.AppError "Missing caller for household_uuid"
+// @Failure 	403 		  {object} apperror.AppError "Caller is not a member of the household or not the user"
 // @Failure 	418 		  {object} apperror.AppError "Something wrong with application logic"
 // @Failure 	500 		  {object} apperror.AppError "Internal server error"
 // @Router /stats [get]
 func (h *handler) GetOperations(w http.ResponseWriter, r *http.Request) error {
-	h.logger.Info("Get operations")
-	defer utils.CloseBody(h.logger, r.Body)
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get operations")
+	defer utils.CloseBody(logger, r.Body)
 	w.Header().Set("Content-Type", "application/json")
 
 	var sortOptions sort.Options
@@ -64,65 +92,104 @@ func (h *handler) GetOperations(w http.ResponseWriter, r *http.Request) error {
 		sortOptions = options
 	}
 
+	filterOptions, err := parseFilterOptions(r)
+	if err != nil {
+		return err
+	}
+
+	// the version and the report are read from one snapshot so that the ETag describes the body
+	var tag string
+	var report *entity.Report
+	err = h.service.Snapshot(r.Context(), func(ctx context.Context) error {
+		version, err := h.service.GetVersion(ctx, filterOptions)
+		if err != nil {
+			return err
+		}
+
+		tag = etag.Strong(filter.CanonicalForm(filterOptions), sortOptions.Field, sortOptions.Order,
+			strconv.FormatInt(version.OperationsCount, 10), strconv.FormatInt(version.RowVersion, 10),
+			version.LastDateTime.UTC().Format(time.RFC3339Nano))
+		if etag.NoneMatch(r, tag) {
+			return nil
+		}
+
+		all, err := h.service.GetAll(ctx, sortOptions, filterOptions)
+		if err != nil {
+			return err
+		}
+		report = &all
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set(etag.HeaderETag, tag)
+	w.Header().Set(etag.HeaderCacheControl, etag.DefaultCacheControl)
+	if report == nil {
+		w.WriteHeader(http.StatusNotModified)
+		logger.Info("Get operations not modified")
+		return nil
+	}
+
+	dataBytes, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal operations: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get operations successfully")
+	return nil
+}
+
+func parseFilterOptions(r *http.Request) (filter.Options, error) {
 	filterOptions := r.Context().Value(filter.OptionsContextKey).(filter.Options)
 
 	var err error
 	userUUID := r.URL.Query().Get(entity.UserUUID)
 	filterOptions, err = processParam(userUUID, filter.DataTypeString, entity.UserUUID, filterOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	categoryName := r.URL.Query().Get(entity.CategoryName)
 	filterOptions, err = processParam(categoryName, filter.DataTypeString, entity.CategoryName, filterOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	categoryType := r.URL.Query().Get(entity.TypeOfCategory)
 	filterOptions, err = processParam(categoryType, filter.DataTypeString, entity.TypeOfCategory, filterOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	categoryUUID := r.URL.Query().Get(entity.CategoryUUID)
 	filterOptions, err = processParam(categoryUUID, filter.DataTypeString, entity.CategoryUUID, filterOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	description := r.URL.Query().Get(entity.Description)
 	filterOptions, err = processParam(description, filter.DataTypeString, entity.Description, filterOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	moneySum := r.URL.Query().Get(entity.MoneySum)
 	filterOptions, err = processParam(moneySum, filter.DataTypeFloat, entity.MoneySum, filterOptions)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	dateTime := r.URL.Query().Get(entity.DateTime)
 	filterOptions, err = processParam(dateTime, filter.DataTypeDate, entity.DateTime, filterOptions)
 	if err != nil {
-		return err
-	}
-
-	report, err := h.service.GetAll(r.Context(), sortOptions, filterOptions)
-	if err != nil {
-		return err
-	}
-
-	dataBytes, err := json.Marshal(report)
-	if err != nil {
-		return fmt.Errorf("failed to marshal operations: %w", err)
+		return nil, err
 	}
 
-	w.WriteHeader(http.StatusOK)
-	_, _ = w.Write(dataBytes)
-	h.logger.Info("Get operations successfully")
-	return nil
+	return filterOptions, nil
 }
 
 func processParam(param, paramType, fieldName string, options filter.Options) (filter.Options, error) {
@@ -0,0 +1,334 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/julienschmidt/httprouter"
+	"net/http"
+	"stats-service/internal/apperror"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/utils"
+	"strings"
+)
+
+const (
+	householdsURL      = "/api/households"
+	householdURL       = "/api/households/:uuid"
+	householdMemberURL = "/api/households/:uuid/members/:user_uuid"
+
+	// HeaderUserUUID identifies the user a request is made on behalf of. It is expected to be set
+	// by the gateway after authenticating the user.
+	HeaderUserUUID = "X-User-UUID"
+)
+
+type householdHandler struct {
+	service HouseholdService
+	logger  *logging.Logger
+}
+
+func NewHouseholdHandler(service HouseholdService, logger *logging.Logger) Handler {
+	return &householdHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func (h *householdHandler) Register(router *httprouter.Router) {
+	router.HandlerFunc(http.MethodGet, householdsURL, apperror.Middleware(h.GetHouseholds))
+	router.HandlerFunc(http.MethodPost, householdsURL, apperror.Middleware(h.CreateHousehold))
+	router.HandlerFunc(http.MethodGet, householdURL, apperror.Middleware(h.GetHousehold))
+	router.HandlerFunc(http.MethodDelete, householdURL, apperror.Middleware(h.DeleteHousehold))
+	router.HandlerFunc(http.MethodPut, householdMemberURL, apperror.Middleware(h.SaveHouseholdMember))
+	router.HandlerFunc(http.MethodDelete, householdMemberURL, apperror.Middleware(h.DeleteHouseholdMember))
+}
+
+// GetHouseholds
+// @Summary 	Get households
+// @Description Lists the households the caller is a member of or invited to.
+// @Tags 		Households
+// @Produce 	json
+// @Param 		X-User-UUID header 	 string true "Caller UUID"
+// @Success 	200 		{array}  entity.Household "Households of the caller"
+// @Failure 	401 		{object} apperror.AppError "Missing caller"
+// @Failure 	418 		{object} apperror.AppError "Something wrong with application logic"
+// @Router /households [get]
+func (h *householdHandler) GetHouseholds(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get households")
+	w.Header().Set("Content-Type", "application/json")
+
+	caller, err := callerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	households, err := h.service.GetAll(r.Context(), caller)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(households)
+	if err != nil {
+		return fmt.Errorf("failed to marshal households: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get households successfully")
+	return nil
+}
+
+// CreateHousehold
+// @Summary 	Create household
+// @Description Creates a household with the caller as its owner.
+// @Tags 		Households
+// @Accept 		json
+// @Produce 	json
+// @Param 		X-User-UUID header 	 string 			 true "Caller UUID"
+// @Param 		household 	body 	 entity.HouseholdDTO true "Household"
+// @Success 	201 		{object} entity.Household "Created household"
+// @Failure 	400 		{object} apperror.AppError "Validation error"
+// @Failure 	401 		{object} apperror.AppError "Missing caller"
+// @Failure 	418 		{object} apperror.AppError "Something wrong with application logic"
+// @Router /households [post]
+func (h *householdHandler) CreateHousehold(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Create household")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	caller, err := callerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	var dto entity.HouseholdDTO
+	if err = json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON body")
+	}
+
+	household, err := h.service.Create(r.Context(), caller, dto)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(household)
+	if err != nil {
+		return fmt.Errorf("failed to marshal household: %w", err)
+	}
+
+	w.Header().Set("Location", householdsURL+"/"+household.UUID)
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Create household successfully")
+	return nil
+}
+
+// GetHousehold
+// @Summary 	Get household
+// @Tags 		Households
+// @Produce 	json
+// @Param 		X-User-UUID header 	 string true "Caller UUID"
+// @Param 		uuid 		path 	 string true "Household UUID"
+// @Success 	200 		{object} entity.Household "Household with its members"
+// @Failure 	401 		{object} apperror.AppError "Missing caller"
+// @Failure 	403 		{object} apperror.AppError "Caller is not a member"
+// @Failure 	404 		{object} apperror.AppError "Household not found"
+// @Failure 	418 		{object} apperror.AppError "Something wrong with application logic"
+// @Router /households/{uuid} [get]
+func (h *householdHandler) GetHousehold(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Get household")
+	w.Header().Set("Content-Type", "application/json")
+
+	caller, err := callerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	uuid := httprouter.ParamsFromContext(r.Context()).ByName("uuid")
+	household, err := h.service.GetOne(r.Context(), caller, uuid)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(household)
+	if err != nil {
+		return fmt.Errorf("failed to marshal household: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Get household successfully")
+	return nil
+}
+
+// DeleteHousehold
+// @Summary 	Delete household
+// @Description Deletes a household. Only owners can delete it; the operations of the members are not affected.
+// @Tags 		Households
+// @Param 		X-User-UUID header 	 string true "Caller UUID"
+// @Param 		uuid 		path 	 string true "Household UUID"
+// @Success 	204 		"Household deleted"
+// @Failure 	401 		{object} apperror.AppError "Missing caller"
+// @Failure 	403 		{object} apperror.AppError "Caller is not an owner"
+// @Failure 	404 		{object} apperror.AppError "Household not found"
+// @Failure 	418 		{object} apperror.AppError "Something wrong with application logic"
+// @Router /households/{uuid} [delete]
+func (h *householdHandler) DeleteHousehold(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Delete household")
+
+	caller, err := callerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	uuid := httprouter.ParamsFromContext(r.Context()).ByName("uuid")
+	if err = h.service.Delete(r.Context(), caller, uuid); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("Delete household successfully")
+	return nil
+}
+
+// SaveHouseholdMember
+// @Summary 	Invite, accept or update household member
+// @Description Invites a user to a household or changes their role (owner or member, default: member). Only owners manage members, and the last owner cannot be demoted. Invited users take part in the household once they accept by saving themselves; the role is chosen by the owner.
+// @Tags 		Households
+// @Accept 		json
+// @Produce 	json
+// @Param 		X-User-UUID header 	 string 				   true "Caller UUID"
+// @Param 		uuid 		path 	 string 				   true "Household UUID"
+// @Param 		user_uuid 	path 	 string 				   true "Member UUID"
+// @Param 		member 		body 	 entity.HouseholdMemberDTO true "Membership"
+// @Success 	200 		{object} entity.Household "Household with its members"
+// @Failure 	400 		{object} apperror.AppError "Validation error"
+// @Failure 	401 		{object} apperror.AppError "Missing caller"
+// @Failure 	403 		{object} apperror.AppError "Caller is not an owner nor accepting their invitation"
+// @Failure 	404 		{object} apperror.AppError "Household not found"
+// @Failure 	418 		{object} apperror.AppError "Something wrong with application logic"
+// @Router /households/{uuid}/members/{user_uuid} [put]
+func (h *householdHandler) SaveHouseholdMember(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Save household member")
+	defer utils.CloseBody(logger, r.Body)
+	w.Header().Set("Content-Type", "application/json")
+
+	caller, err := callerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	var dto entity.HouseholdMemberDTO
+	if err = json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		return apperror.BadRequestError("invalid JSON body")
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	household, err := h.service.SaveMember(r.Context(), caller, params.ByName("uuid"), params.ByName("user_uuid"), dto)
+	if err != nil {
+		return err
+	}
+
+	dataBytes, err := json.Marshal(household)
+	if err != nil {
+		return fmt.Errorf("failed to marshal household: %w", err)
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(dataBytes)
+	logger.Info("Save household member successfully")
+	return nil
+}
+
+// DeleteHouseholdMember
+// @Summary 	Remove household member
+// @Description Removes a user or an invitation from a household. Owners can remove anybody, members and invited users only themselves. The last owner cannot leave.
+// @Tags 		Households
+// @Param 		X-User-UUID header 	 string true "Caller UUID"
+// @Param 		uuid 		path 	 string true "Household UUID"
+// @Param 		user_uuid 	path 	 string true "Member UUID"
+// @Success 	204 		"Member removed"
+// @Failure 	400 		{object} apperror.AppError "Last owner cannot be removed"
+// @Failure 	401 		{object} apperror.AppError "Missing caller"
+// @Failure 	403 		{object} apperror.AppError "Caller may not remove the member"
+// @Failure 	404 		{object} apperror.AppError "Household or member not found"
+// @Failure 	418 		{object} apperror.AppError "Something wrong with application logic"
+// @Router /households/{uuid}/members/{user_uuid} [delete]
+func (h *householdHandler) DeleteHouseholdMember(w http.ResponseWriter, r *http.Request) error {
+	logger := logging.LoggerFromContext(r.Context(), h.logger)
+	logger.Info("Delete household member")
+
+	caller, err := callerUUID(r)
+	if err != nil {
+		return err
+	}
+
+	params := httprouter.ParamsFromContext(r.Context())
+	if err = h.service.DeleteMember(r.Context(), caller, params.ByName("uuid"), params.ByName("user_uuid")); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	logger.Info("Delete household member successfully")
+	return nil
+}
+
+func callerUUID(r *http.Request) (string, error) {
+	caller := r.Header.Get(HeaderUserUUID)
+	if caller == "" {
+		return "", apperror.ErrUnauthorized
+	}
+	return caller, nil
+}
+
+// scopeToHousehold replaces the household_uuid query parameter with a user_uuid filter listing
+// all members of the household, or the single member given in user_uuid, once the caller is
+// verified to be a member. Without household_uuid the user_uuid filter may only name one user,
+// which defaults to and must be the caller when X-User-UUID is set.
+func scopeToHousehold(households HouseholdService, next func(http.ResponseWriter, *http.Request) error) func(http.ResponseWriter, *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		query := r.URL.Query()
+		householdUUID := query.Get(entity.HouseholdUUID)
+		userUUID := query.Get(entity.UserUUID)
+		if householdUUID == "" {
+			if strings.Contains(userUUID, ",") {
+				return paramError(entity.UserUUID, "must be a single user, use household_uuid to report on several users")
+			}
+			if caller := r.Header.Get(HeaderUserUUID); caller != "" {
+				if userUUID != "" && userUUID != caller {
+					return apperror.ErrForbidden
+				}
+				query.Set(entity.UserUUID, caller)
+				r.URL.RawQuery = query.Encode()
+			}
+			return next(w, r)
+		}
+
+		caller, err := callerUUID(r)
+		if err != nil {
+			return err
+		}
+		household, err := households.GetOne(r.Context(), caller, householdUUID)
+		if err != nil {
+			return err
+		}
+
+		users := household.MemberUUIDs()
+		if userUUID != "" {
+			if _, ok := household.Member(userUUID); !ok {
+				return apperror.ErrForbidden
+			}
+			users = []string{userUUID}
+		}
+
+		query.Set(entity.UserUUID, strings.Join(users, ","))
+		query.Del(entity.HouseholdUUID)
+		r.URL.RawQuery = query.Encode()
+		return next(w, r)
+	}
+}
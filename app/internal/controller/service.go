@@ -5,8 +5,61 @@ import (
 	"stats-service/internal/domain/entity"
 	"stats-service/pkg/api/filter"
 	"stats-service/pkg/api/sort"
+	"time"
 )
 
 type Service interface {
 	GetAll(ctx context.Context, sortOptions sort.Options, filterOptions filter.Options) (entity.Report, error)
+	Explain(ctx context.Context, sortOptions sort.Options, filterOptions filter.Options) (entity.QueryPlan, error)
+	GetVersion(ctx context.Context, filterOptions filter.Options) (entity.ReportVersion, error)
+	// Snapshot runs fn so that every call made with the context passed to it reads the same data.
+	Snapshot(ctx context.Context, fn func(ctx context.Context) error) error
+	GetRecurring(ctx context.Context, filterOptions filter.Options) (entity.RecurringReport, error)
+	GetAnomalies(ctx context.Context, filterOptions filter.Options, options entity.AnomalyOptions) (entity.AnomalyReport, error)
+	GetForecast(ctx context.Context, filterOptions filter.Options, horizonDays int) (entity.ForecastReport, error)
+	GetComparison(ctx context.Context, filterOptions filter.Options, current, previous entity.DateRange) (entity.ComparisonReport, error)
+	GetBalance(ctx context.Context, filterOptions filter.Options, interval string, openingBalance float64) (entity.BalanceReport, error)
+	GetHistogram(ctx context.Context, filterOptions filter.Options, buckets int, scale string) (entity.Histogram, error)
+	GetTop(ctx context.Context, filterOptions filter.Options, by, metric string) (entity.TopReport, error)
+	GetHeatmap(ctx context.Context, filterOptions filter.Options, timezone string) (entity.Heatmap, error)
+}
+
+type SlowQueryLog interface {
+	SlowQueries() []entity.SlowQuery
+}
+
+type BudgetService interface {
+	Create(ctx context.Context, dto entity.BudgetDTO) (entity.Budget, error)
+	GetAll(ctx context.Context, userUUID string) ([]entity.Budget, error)
+	GetOne(ctx context.Context, userUUID, uuid string) (entity.Budget, error)
+	Update(ctx context.Context, uuid string, dto entity.BudgetDTO) (entity.Budget, error)
+	Delete(ctx context.Context, userUUID, uuid string) error
+	GetReport(ctx context.Context, userUUIDs []string, date time.Time) (entity.BudgetReport, error)
+}
+
+type GoalService interface {
+	Create(ctx context.Context, dto entity.GoalDTO) (entity.Goal, error)
+	GetAll(ctx context.Context, userUUID string) ([]entity.Goal, error)
+	GetOne(ctx context.Context, userUUID, uuid string) (entity.Goal, error)
+	Update(ctx context.Context, uuid string, dto entity.GoalDTO) (entity.Goal, error)
+	Delete(ctx context.Context, userUUID, uuid string) error
+	GetReport(ctx context.Context, userUUIDs []string, date time.Time) (entity.GoalReport, error)
+}
+
+// HouseholdService checks every call against the caller, the user the request is made on behalf of.
+type HouseholdService interface {
+	Create(ctx context.Context, callerUUID string, dto entity.HouseholdDTO) (entity.Household, error)
+	GetAll(ctx context.Context, callerUUID string) ([]entity.Household, error)
+	GetOne(ctx context.Context, callerUUID, uuid string) (entity.Household, error)
+	Delete(ctx context.Context, callerUUID, uuid string) error
+	SaveMember(ctx context.Context, callerUUID, uuid, userUUID string, dto entity.HouseholdMemberDTO) (entity.Household, error)
+	DeleteMember(ctx context.Context, callerUUID, uuid, userUUID string) error
+}
+
+type AlertService interface {
+	Create(ctx context.Context, dto entity.AlertRuleDTO) (entity.AlertRule, error)
+	GetAll(ctx context.Context, userUUID string) ([]entity.AlertRule, error)
+	GetOne(ctx context.Context, userUUID, uuid string) (entity.AlertRule, error)
+	Update(ctx context.Context, uuid string, dto entity.AlertRuleDTO) (entity.AlertRule, error)
+	Delete(ctx context.Context, userUUID, uuid string) error
 }
@@ -0,0 +1,169 @@
+// Package analytics holds the statistics used by the stats reports. It works on plain values
+// and time series so it can be fed by any repository query.
+package analytics
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// madScale makes the median absolute deviation comparable to a standard deviation for normally
+	// distributed data, giving the modified z-score of Iglewicz and Hoaglin.
+	madScale = 0.6745
+	// meanADScale does the same for the mean absolute deviation, used when the MAD is zero.
+	meanADScale = 1.253314
+)
+
+func Median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	middle := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[middle-1] + sorted[middle]) / 2
+	}
+	return sorted[middle]
+}
+
+// MAD returns the median absolute deviation of values from their median.
+func MAD(values []float64) float64 {
+	median := Median(values)
+	deviations := make([]float64, 0, len(values))
+	for _, value := range values {
+		deviations = append(deviations, math.Abs(value-median))
+	}
+	return Median(deviations)
+}
+
+// MeanAD returns the mean absolute deviation of values from their median.
+func MeanAD(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	median := Median(values)
+	sum := 0.0
+	for _, value := range values {
+		sum += math.Abs(value - median)
+	}
+	return sum / float64(len(values))
+}
+
+type Baseline struct {
+	Median float64
+	MAD    float64
+	MeanAD float64
+	Size   int
+}
+
+func NewBaseline(values []float64) Baseline {
+	return Baseline{
+		Median: Median(values),
+		MAD:    MAD(values),
+		MeanAD: MeanAD(values),
+		Size:   len(values),
+	}
+}
+
+// ZScore returns the modified z-score of value. When more than half of the baseline values are
+// equal, for example days without spending, the MAD is zero and the mean absolute deviation is
+// used instead. The score is not defined when all baseline values are equal.
+func (b Baseline) ZScore(value float64) (float64, bool) {
+	switch {
+	case b.MAD > 0:
+		return madScale * (value - b.Median) / b.MAD, true
+	case b.MeanAD > 0:
+		return (value - b.Median) / (meanADScale * b.MeanAD), true
+	default:
+		return 0, false
+	}
+}
+
+type Point struct {
+	Time  time.Time
+	Value float64
+}
+
+type Outlier struct {
+	Index    int
+	Point    Point
+	Baseline Baseline
+	ZScore   float64
+}
+
+// TrailingOutliers compares every point with the points in the window before it and returns the
+// ones whose absolute modified z-score is at least threshold. Points with fewer than minBaseline
+// predecessors in the window are not judged. points must be sorted by time.
+func TrailingOutliers(points []Point, window time.Duration, minBaseline int, threshold float64) []Outlier {
+	outliers := make([]Outlier, 0)
+	first := 0
+	for i, point := range points {
+		for first < i && point.Time.Sub(points[first].Time) > window {
+			first++
+		}
+		if i-first < minBaseline {
+			continue
+		}
+
+		values := make([]float64, 0, i-first)
+		for _, previous := range points[first:i] {
+			values = append(values, previous.Value)
+		}
+		baseline := NewBaseline(values)
+		score, ok := baseline.ZScore(point.Value)
+		if ok && math.Abs(score) >= threshold {
+			outliers = append(outliers, Outlier{Index: i, Point: point, Baseline: baseline, ZScore: score})
+		}
+	}
+	return outliers
+}
+
+// DailyTotals sums points per calendar day in UTC, including the days without points
+// between the first and the last one. points must be sorted by time.
+func DailyTotals(points []Point) []Point {
+	if len(points) == 0 {
+		return nil
+	}
+	return DailyTotalsBetween(points, points[0].Time, points[len(points)-1].Time)
+}
+
+// DailyTotalsBetween sums points per calendar day in UTC for every day from from to to inclusive.
+// Points outside the range are ignored. points must be sorted by time.
+func DailyTotalsBetween(points []Point, from, to time.Time) []Point {
+	return totals(points, from, to, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}, func(t time.Time) time.Time {
+		return t.AddDate(0, 0, 1)
+	})
+}
+
+// MonthlyTotals sums points per calendar month in UTC, including the empty months
+// between the first and the last one. points must be sorted by time.
+func MonthlyTotals(points []Point) []Point {
+	if len(points) == 0 {
+		return nil
+	}
+	return totals(points, points[0].Time, points[len(points)-1].Time, func(t time.Time) time.Time {
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}, func(t time.Time) time.Time {
+		return t.AddDate(0, 1, 0)
+	})
+}
+
+func totals(points []Point, from, to time.Time, truncate func(time.Time) time.Time,
+	next func(time.Time) time.Time) []Point {
+	from, to = truncate(from), truncate(to)
+	sums := make(map[time.Time]float64)
+	for _, point := range points {
+		sums[truncate(point.Time)] += point.Value
+	}
+
+	series := make([]Point, 0)
+	for bucket := from; !bucket.After(to); bucket = next(bucket) {
+		series = append(series, Point{Time: bucket, Value: sums[bucket]})
+	}
+	return series
+}
@@ -0,0 +1,85 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBaselineZScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []float64
+		value  float64
+		score  float64
+		ok     bool
+	}{
+		{name: "empty", values: nil, value: 10},
+		{name: "single value", values: []float64{5}, value: 10},
+		{name: "all equal", values: []float64{5, 5, 5, 5}, value: 10},
+		{name: "median absolute deviation", values: []float64{1, 2, 3, 4, 5}, value: 5, score: madScale * 2, ok: true},
+		{name: "below the median", values: []float64{1, 2, 3, 4, 5}, value: 1, score: -madScale * 2, ok: true},
+		{name: "mean absolute deviation when most values are equal", values: []float64{0, 0, 0, 10}, value: 10,
+			score: 10 / (meanADScale * 2.5), ok: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, ok := NewBaseline(tt.values).ZScore(tt.value)
+			if ok != tt.ok {
+				t.Fatalf("ZScore() ok = %v, want %v", ok, tt.ok)
+			}
+			if math.Abs(score-tt.score) > 1e-9 {
+				t.Errorf("ZScore() = %v, want %v", score, tt.score)
+			}
+		})
+	}
+}
+
+func TestTrailingOutliers(t *testing.T) {
+	start := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	daily := func(values ...float64) []Point {
+		points := make([]Point, 0, len(values))
+		for i, value := range values {
+			points = append(points, Point{Time: start.AddDate(0, 0, i), Value: value})
+		}
+		return points
+	}
+	week := 7 * 24 * time.Hour
+
+	tests := []struct {
+		name    string
+		points  []Point
+		indexes []int
+	}{
+		{name: "empty", points: nil},
+		{name: "single point", points: daily(100)},
+		{name: "all equal", points: daily(10, 10, 10, 10, 10, 10, 10)},
+		{name: "too few predecessors", points: daily(10, 12, 11, 100)},
+		{name: "spike", points: daily(10, 12, 11, 9, 10, 100), indexes: []int{5}},
+		{name: "drop", points: daily(10, 12, 11, 9, 10, 0), indexes: []int{5}},
+		{name: "within threshold", points: daily(10, 12, 11, 9, 10, 13)},
+		{
+			name:   "predecessors outside the window",
+			points: append(daily(10, 12, 11, 9, 10), Point{Time: start.AddDate(0, 0, 30), Value: 100}),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outliers := TrailingOutliers(tt.points, week, 5, 3.5)
+			if outliers == nil {
+				t.Fatal("TrailingOutliers() = nil, want a slice")
+			}
+			if len(outliers) != len(tt.indexes) {
+				t.Fatalf("TrailingOutliers() found %d outliers, want %d", len(outliers), len(tt.indexes))
+			}
+			for i, outlier := range outliers {
+				if outlier.Index != tt.indexes[i] || outlier.Point != tt.points[tt.indexes[i]] {
+					t.Errorf("outlier %d is point %d, want %d", i, outlier.Index, tt.indexes[i])
+				}
+				if outlier.Baseline.Size != 5 {
+					t.Errorf("outlier %d baseline size = %d, want 5", i, outlier.Baseline.Size)
+				}
+			}
+		})
+	}
+}
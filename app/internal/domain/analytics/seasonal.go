@@ -0,0 +1,73 @@
+package analytics
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// seasonalMinWeekdays is how many observations of a weekday are needed to estimate its factor
+	seasonalMinWeekdays = 4
+	// seasonalMinMonthDays is how many days of a month of the year are needed to estimate its factor
+	seasonalMinMonthDays = 28
+)
+
+// Seasonal models a daily series as its mean scaled by a day-of-week and a month-of-year factor.
+// Factors without enough observations stay at 1.
+type Seasonal struct {
+	Mean       float64
+	Weekday    [7]float64
+	Month      [12]float64
+	ResidualSD float64
+}
+
+// FitSeasonal fits the model to a dense daily series, see DailyTotalsBetween.
+func FitSeasonal(daily []Point) Seasonal {
+	var model Seasonal
+	for i := range model.Weekday {
+		model.Weekday[i] = 1
+	}
+	for i := range model.Month {
+		model.Month[i] = 1
+	}
+	if len(daily) == 0 {
+		return model
+	}
+
+	var weekdaySum, weekdayCount [7]float64
+	var monthSum, monthCount [12]float64
+	sum := 0.0
+	for _, point := range daily {
+		sum += point.Value
+		weekdaySum[point.Time.Weekday()] += point.Value
+		weekdayCount[point.Time.Weekday()]++
+		monthSum[point.Time.Month()-1] += point.Value
+		monthCount[point.Time.Month()-1]++
+	}
+	model.Mean = sum / float64(len(daily))
+
+	if model.Mean != 0 {
+		for i := range model.Weekday {
+			if weekdayCount[i] >= seasonalMinWeekdays {
+				model.Weekday[i] = weekdaySum[i] / weekdayCount[i] / model.Mean
+			}
+		}
+		for i := range model.Month {
+			if monthCount[i] >= seasonalMinMonthDays {
+				model.Month[i] = monthSum[i] / monthCount[i] / model.Mean
+			}
+		}
+	}
+
+	squares := 0.0
+	for _, point := range daily {
+		residual := point.Value - model.Predict(point.Time)
+		squares += residual * residual
+	}
+	model.ResidualSD = math.Sqrt(squares / float64(len(daily)))
+	return model
+}
+
+func (s Seasonal) Predict(t time.Time) float64 {
+	return s.Mean * s.Weekday[t.Weekday()] * s.Month[t.Month()-1]
+}
@@ -0,0 +1,64 @@
+package analytics
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestFitSeasonal(t *testing.T) {
+	// a Monday, so four weeks cover every weekday four times and 28 days of March
+	start := time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC)
+	daily := func(days int, value func(day time.Time) float64) []Point {
+		points := make([]Point, 0, days)
+		for i := 0; i < days; i++ {
+			day := start.AddDate(0, 0, i)
+			points = append(points, Point{Time: day, Value: value(day)})
+		}
+		return points
+	}
+	constant := func(value float64) func(time.Time) float64 {
+		return func(time.Time) float64 { return value }
+	}
+	saturdays := func(day time.Time) float64 {
+		if day.Weekday() == time.Saturday {
+			return 70
+		}
+		return 0
+	}
+
+	tests := []struct {
+		name       string
+		daily      []Point
+		mean       float64
+		saturday   float64
+		monday     float64
+		march      float64
+		residualSD float64
+	}{
+		{name: "empty", daily: nil, saturday: 1, monday: 1, march: 1},
+		{name: "single day", daily: daily(1, constant(12)), mean: 12, saturday: 1, monday: 1, march: 1},
+		{name: "all equal", daily: daily(28, constant(5)), mean: 5, saturday: 1, monday: 1, march: 1},
+		{name: "all zero", daily: daily(28, constant(0)), saturday: 1, monday: 1, march: 1},
+		{name: "too few weeks for weekday factors", daily: daily(21, saturdays), mean: 10, saturday: 1, monday: 1, march: 1,
+			residualSD: math.Sqrt((3*60*60 + 18*10*10) / 21.0)},
+		{name: "weekly pattern", daily: daily(28, saturdays), mean: 10, saturday: 7, monday: 0, march: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			model := FitSeasonal(tt.daily)
+			check := func(name string, got, want float64) {
+				if math.Abs(got-want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", name, got, want)
+				}
+			}
+			check("mean", model.Mean, tt.mean)
+			check("saturday factor", model.Weekday[time.Saturday], tt.saturday)
+			check("monday factor", model.Weekday[time.Monday], tt.monday)
+			check("march factor", model.Month[time.March-1], tt.march)
+			check("january factor", model.Month[time.January-1], 1)
+			check("residual sd", model.ResidualSD, tt.residualSD)
+			check("saturday prediction", model.Predict(start.AddDate(0, 0, 5)), tt.mean*tt.saturday*tt.march)
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package entity
+
+import "time"
+
+type AlertKind string
+
+const (
+	// AlertKindThreshold fires when spending in the current period reaches Threshold.
+	AlertKindThreshold AlertKind = "threshold"
+	// AlertKindBudgetPercent fires when spending reaches Threshold percent of a budget.
+	AlertKindBudgetPercent AlertKind = "budget_percent"
+	// AlertKindGrowth fires when spending so far in the current period is at least Threshold percent
+	// higher than in the same part of the previous period.
+	AlertKindGrowth AlertKind = "growth"
+)
+
+const AlertEventTriggered = "alert.triggered"
+
+type AlertRule struct {
+	UUID         string       `json:"uuid"`
+	UserUUID     string       `json:"user_uuid"`
+	Kind         AlertKind    `json:"kind"`
+	CategoryUUID string       `json:"category_uuid,omitempty"`
+	CategoryType CategoryType `json:"category_type,omitempty"`
+	BudgetUUID   string       `json:"budget_uuid,omitempty"`
+	Period       BudgetPeriod `json:"period,omitempty"`
+	Threshold    float64      `json:"threshold"`
+	WebhookURL   string       `json:"webhook_url"`
+	Enabled      bool         `json:"enabled"`
+	State        *AlertState  `json:"state,omitempty"`
+}
+
+type AlertRuleDTO struct {
+	UserUUID     string       `json:"user_uuid"`
+	Kind         AlertKind    `json:"kind"`
+	CategoryUUID string       `json:"category_uuid"`
+	CategoryType CategoryType `json:"category_type"`
+	BudgetUUID   string       `json:"budget_uuid"`
+	Period       BudgetPeriod `json:"period"`
+	Threshold    float64      `json:"threshold"`
+	WebhookURL   string       `json:"webhook_url"`
+	Enabled      *bool        `json:"enabled"`
+}
+
+// AlertState is the outcome of the last evaluation of a rule. Firing is only set once
+// a notification has been delivered, so each rule notifies at most once per period.
+type AlertState struct {
+	RuleUUID    string     `json:"-"`
+	PeriodStart time.Time  `json:"period_start"`
+	Firing      bool       `json:"firing"`
+	LastValue   float64    `json:"last_value"`
+	TriggeredAt *time.Time `json:"triggered_at,omitempty"`
+	EvaluatedAt time.Time  `json:"evaluated_at"`
+}
+
+type AlertNotification struct {
+	Event        string       `json:"event"`
+	RuleUUID     string       `json:"rule_uuid"`
+	UserUUID     string       `json:"user_uuid"`
+	Kind         AlertKind    `json:"kind"`
+	CategoryUUID string       `json:"category_uuid,omitempty"`
+	CategoryType CategoryType `json:"category_type,omitempty"`
+	BudgetUUID   string       `json:"budget_uuid,omitempty"`
+	Threshold    float64      `json:"threshold"`
+	Value        float64      `json:"value"`
+	PeriodStart  time.Time    `json:"period_start"`
+	PeriodEnd    time.Time    `json:"period_end"`
+	TriggeredAt  time.Time    `json:"triggered_at"`
+}
@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+const (
+	AnomalyPeriodDay   = "day"
+	AnomalyPeriodMonth = "month"
+)
+
+type AnomalyOptions struct {
+	// WindowDays is the length of the trailing window the baseline is taken from.
+	WindowDays int
+	// Threshold is the absolute modified z-score from which a value is reported.
+	Threshold float64
+}
+
+// AnomalyBaseline describes the values a value was compared with: their median,
+// median absolute deviation and count.
+type AnomalyBaseline struct {
+	Median float64 `json:"median"`
+	MAD    float64 `json:"mad"`
+	Size   int     `json:"size"`
+}
+
+type OperationAnomaly struct {
+	Operation Operation       `json:"operation"`
+	Baseline  AnomalyBaseline `json:"baseline"`
+	Deviation float64         `json:"deviation"`
+	ZScore    float64         `json:"z_score"`
+}
+
+type PeriodAnomaly struct {
+	Period    string          `json:"period"`
+	Start     time.Time       `json:"start"`
+	Total     float64         `json:"total"`
+	Baseline  AnomalyBaseline `json:"baseline"`
+	Deviation float64         `json:"deviation"`
+	ZScore    float64         `json:"z_score"`
+}
+
+type AnomalyReport struct {
+	WindowDays int                `json:"window_days"`
+	Threshold  float64            `json:"threshold"`
+	Operations []OperationAnomaly `json:"operations"`
+	Days       []PeriodAnomaly    `json:"days"`
+	Months     []PeriodAnomaly    `json:"months"`
+	// Members break the totals of the analysed operations down per user when they belong to several users.
+	Members []MemberTotal `json:"members,omitempty"`
+}
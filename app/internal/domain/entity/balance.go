@@ -0,0 +1,39 @@
+package entity
+
+import "time"
+
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
+// NextInterval returns the start of the interval following the one starting at t.
+func NextInterval(interval string, t time.Time) time.Time {
+	switch interval {
+	case IntervalWeek:
+		return t.AddDate(0, 0, 7)
+	case IntervalMonth:
+		return t.AddDate(0, 1, 0)
+	default:
+		return t.AddDate(0, 0, 1)
+	}
+}
+
+// BalancePoint holds the income and expense within an interval and the balance at its end.
+type BalancePoint struct {
+	Date    time.Time `json:"date"`
+	Income  float64   `json:"income"`
+	Expense float64   `json:"expense"`
+	Net     float64   `json:"net"`
+	Balance float64   `json:"balance"`
+}
+
+type BalanceReport struct {
+	Interval       string         `json:"interval"`
+	OpeningBalance float64        `json:"opening_balance"`
+	ClosingBalance float64        `json:"closing_balance"`
+	Points         []BalancePoint `json:"points"`
+	// Members break the totals down per user when the report covers several users.
+	Members []MemberTotal `json:"members,omitempty"`
+}
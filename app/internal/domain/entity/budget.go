@@ -0,0 +1,116 @@
+package entity
+
+import "time"
+
+type BudgetPeriod string
+
+const (
+	BudgetPeriodMonthly BudgetPeriod = "monthly"
+	BudgetPeriodWeekly  BudgetPeriod = "weekly"
+	BudgetPeriodCustom  BudgetPeriod = "custom"
+)
+
+const DateLayout = "2006-01-02"
+
+// Budget limits spending of a user either in a single category or in all categories of a type.
+// Exactly one of CategoryUUID and CategoryType is set.
+type Budget struct {
+	UUID         string       `json:"uuid"`
+	UserUUID     string       `json:"user_uuid"`
+	CategoryUUID string       `json:"category_uuid,omitempty"`
+	CategoryType CategoryType `json:"category_type,omitempty"`
+	Amount       float64      `json:"amount"`
+	Period       BudgetPeriod `json:"period"`
+	StartDate    *time.Time   `json:"start_date,omitempty"`
+	EndDate      *time.Time   `json:"end_date,omitempty"`
+}
+
+type BudgetDTO struct {
+	UserUUID     string       `json:"user_uuid"`
+	CategoryUUID string       `json:"category_uuid"`
+	CategoryType CategoryType `json:"category_type"`
+	Amount       float64      `json:"amount"`
+	Period       BudgetPeriod `json:"period"`
+	StartDate    string       `json:"start_date" example:"2024-01-01"`
+	EndDate      string       `json:"end_date" example:"2024-01-31"`
+}
+
+// PeriodAt returns the budget period containing date as [start, end).
+// A custom period is always the configured date range.
+func (b Budget) PeriodAt(date time.Time) (time.Time, time.Time) {
+	if b.Period == BudgetPeriodCustom && b.StartDate != nil && b.EndDate != nil {
+		return *b.StartDate, b.EndDate.AddDate(0, 0, 1)
+	}
+	return PeriodBounds(b.Period, date)
+}
+
+// PeriodBounds returns the calendar period containing date as [start, end).
+// Weekly periods start on Monday, anything else is treated as a calendar month.
+func PeriodBounds(period BudgetPeriod, date time.Time) (time.Time, time.Time) {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if period == BudgetPeriodWeekly {
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7)
+	}
+	start := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// SpendingScope selects the operations of a user, optionally narrowed to a category or a category type.
+type SpendingScope struct {
+	UserUUID     string
+	CategoryUUID string
+	CategoryType CategoryType
+}
+
+type BudgetStatus struct {
+	Budget           Budget    `json:"budget"`
+	PeriodStart      time.Time `json:"period_start"`
+	PeriodEnd        time.Time `json:"period_end"`
+	Actual           float64   `json:"actual"`
+	Remaining        float64   `json:"remaining"`
+	PercentUsed      float64   `json:"percent_used"`
+	Projected        float64   `json:"projected"`
+	ProjectedOverrun float64   `json:"projected_overrun"`
+}
+
+type BudgetReport struct {
+	Date    time.Time      `json:"date"`
+	Budgets []BudgetStatus `json:"budgets"`
+	// Members break the budgets down per user when the report covers several users.
+	Members []BudgetMemberTotal `json:"members,omitempty"`
+}
+
+// BudgetMemberTotal sums the budgets of one user for the periods in the report.
+type BudgetMemberTotal struct {
+	UserUUID  string  `json:"user_uuid"`
+	Amount    float64 `json:"amount"`
+	Actual    float64 `json:"actual"`
+	Remaining float64 `json:"remaining"`
+}
+
+// NewBudgetStatus compares actual spending with the budget amount. The projection extrapolates
+// the spending so far linearly over the whole period; PeriodEnd is the last day of the period.
+func NewBudgetStatus(budget Budget, date, start, end time.Time, actual float64) BudgetStatus {
+	status := BudgetStatus{
+		Budget:      budget,
+		PeriodStart: start,
+		PeriodEnd:   end.AddDate(0, 0, -1),
+		Actual:      actual,
+		Remaining:   budget.Amount - actual,
+		Projected:   actual,
+	}
+	if budget.Amount > 0 {
+		status.PercentUsed = actual / budget.Amount * 100
+	}
+
+	totalDays := end.Sub(start).Hours() / 24
+	elapsedDays := date.Sub(start).Hours()/24 + 1
+	if elapsedDays > 0 && elapsedDays < totalDays {
+		status.Projected = actual / elapsedDays * totalDays
+	}
+	if status.Projected > budget.Amount {
+		status.ProjectedOverrun = status.Projected - budget.Amount
+	}
+	return status
+}
@@ -0,0 +1,87 @@
+package entity
+
+import "time"
+
+const (
+	ComparePreviousPeriod     = "previous_period"
+	CompareSamePeriodLastYear = "same_period_last_year"
+)
+
+// DateRange covers the days from From to To inclusive.
+type DateRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// Days returns the number of days in the range.
+func (r DateRange) Days() int {
+	return int(r.To.Sub(r.From).Hours()/24) + 1
+}
+
+// End returns the exclusive end of the range.
+func (r DateRange) End() time.Time {
+	return r.To.AddDate(0, 0, 1)
+}
+
+type CategoryTotal struct {
+	CategoryUUID string       `json:"category_uuid"`
+	UserUUID     string       `json:"user_uuid"`
+	CategoryName string       `json:"category_name"`
+	CategoryType CategoryType `json:"category_type"`
+	Total        float64      `json:"total"`
+	Count        int64        `json:"count"`
+}
+
+type ComparisonValue struct {
+	Current  float64 `json:"current"`
+	Previous float64 `json:"previous"`
+	Delta    float64 `json:"delta"`
+	// DeltaPercent is the change relative to the previous value, null when it was zero.
+	DeltaPercent *float64 `json:"delta_percent"`
+}
+
+func NewComparisonValue(current, previous float64) ComparisonValue {
+	value := ComparisonValue{
+		Current:  current,
+		Previous: previous,
+		Delta:    current - previous,
+	}
+	if previous != 0 {
+		percent := value.Delta / previous * 100
+		value.DeltaPercent = &percent
+	}
+	return value
+}
+
+type CategoryComparison struct {
+	CategoryUUID  string          `json:"category_uuid"`
+	CategoryName  string          `json:"category_name"`
+	CategoryType  CategoryType    `json:"category_type"`
+	Total         ComparisonValue `json:"total"`
+	CurrentCount  int64           `json:"current_count"`
+	PreviousCount int64           `json:"previous_count"`
+	// Contribution is the share of the change of the category type total caused by this category, in percent.
+	Contribution float64 `json:"contribution"`
+}
+
+type ComparisonReport struct {
+	Current  DateRange       `json:"current"`
+	Previous DateRange       `json:"previous"`
+	Total    ComparisonValue `json:"total"`
+	Income   ComparisonValue `json:"income"`
+	Expense  ComparisonValue `json:"expense"`
+	// Categories are ordered by the absolute change, largest first.
+	Categories []CategoryComparison `json:"categories"`
+	// TopContributors are the categories that changed the most.
+	TopContributors []CategoryComparison `json:"top_contributors"`
+	// Members break the comparison down per user when it covers several users.
+	Members []MemberComparison `json:"members,omitempty"`
+}
+
+// MemberComparison breaks a comparison over several users down to a single user.
+type MemberComparison struct {
+	UserUUID string          `json:"user_uuid"`
+	Income   ComparisonValue `json:"income"`
+	Expense  ComparisonValue `json:"expense"`
+	Net      ComparisonValue `json:"net"`
+}
@@ -0,0 +1,36 @@
+package entity
+
+const (
+	HistogramLinear = "linear"
+	HistogramLog    = "log"
+)
+
+// Distribution summarises the money sums of a set of operations. Percentiles are interpolated.
+type Distribution struct {
+	Count  int64   `json:"count"`
+	Mean   float64 `json:"mean"`
+	StdDev float64 `json:"stddev"`
+	Min    float64 `json:"min"`
+	Max    float64 `json:"max"`
+	Median float64 `json:"median"`
+	P25    float64 `json:"p25"`
+	P75    float64 `json:"p75"`
+	P90    float64 `json:"p90"`
+	P99    float64 `json:"p99"`
+}
+
+// HistogramBucket counts the operations with From <= money_sum < To; the last bucket includes To.
+type HistogramBucket struct {
+	From  float64 `json:"from"`
+	To    float64 `json:"to"`
+	Count int64   `json:"count"`
+	Sum   float64 `json:"sum"`
+}
+
+type Histogram struct {
+	Scale        string            `json:"scale"`
+	Distribution Distribution      `json:"distribution"`
+	Buckets      []HistogramBucket `json:"buckets"`
+	// Members break the totals down per user when the histogram covers several users.
+	Members []MemberTotal `json:"members,omitempty"`
+}
@@ -0,0 +1,36 @@
+package entity
+
+import "time"
+
+// TypedOperation is an operation with the type of its category, telling income from expense.
+type TypedOperation struct {
+	Operation
+	CategoryType CategoryType `json:"category_type"`
+}
+
+// ForecastValue is a point estimate with the bounds of its prediction interval.
+type ForecastValue struct {
+	Value float64 `json:"value"`
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+}
+
+type ForecastDay struct {
+	Date          time.Time     `json:"date"`
+	Income        ForecastValue `json:"income"`
+	Expense       ForecastValue `json:"expense"`
+	Net           ForecastValue `json:"net"`
+	CumulativeNet ForecastValue `json:"cumulative_net"`
+}
+
+type ForecastReport struct {
+	HorizonDays int `json:"horizon_days"`
+	// HistoryDays is the number of days of history the seasonal averages were computed from.
+	HistoryDays int `json:"history_days"`
+	// IntervalLevel is the probability the lower and upper bounds are meant to cover.
+	IntervalLevel float64           `json:"interval_level"`
+	Recurring     []RecurringSeries `json:"recurring"`
+	Days          []ForecastDay     `json:"days"`
+	// Members break the totals of the history down per user when it covers several users.
+	Members []MemberTotal `json:"members,omitempty"`
+}
@@ -0,0 +1,105 @@
+package entity
+
+import "time"
+
+type GoalRule string
+
+const (
+	// GoalRuleIncome counts income, in a single category when CategoryUUID is set.
+	GoalRuleIncome GoalRule = "income"
+	// GoalRuleNetSavings counts income minus expense.
+	GoalRuleNetSavings GoalRule = "net_savings"
+)
+
+// daysPerMonth is the average length of a month in the Gregorian calendar.
+const daysPerMonth = 365.2425 / 12
+
+// Goal is a target amount a user wants to save between StartDate and Deadline.
+type Goal struct {
+	UUID         string    `json:"uuid"`
+	UserUUID     string    `json:"user_uuid"`
+	Name         string    `json:"name"`
+	TargetAmount float64   `json:"target_amount"`
+	Rule         GoalRule  `json:"rule"`
+	CategoryUUID string    `json:"category_uuid,omitempty"`
+	StartDate    time.Time `json:"start_date"`
+	Deadline     time.Time `json:"deadline"`
+}
+
+type GoalDTO struct {
+	UserUUID     string   `json:"user_uuid"`
+	Name         string   `json:"name"`
+	TargetAmount float64  `json:"target_amount"`
+	Rule         GoalRule `json:"rule"`
+	CategoryUUID string   `json:"category_uuid"`
+	StartDate    string   `json:"start_date" example:"2024-01-01"`
+	Deadline     string   `json:"deadline" example:"2024-12-31"`
+}
+
+type GoalStatus struct {
+	Goal            Goal    `json:"goal"`
+	Saved           float64 `json:"saved"`
+	Remaining       float64 `json:"remaining"`
+	PercentComplete float64 `json:"percent_complete"`
+	Achieved        bool    `json:"achieved"`
+	// MonthlyPace is the average amount saved per month since StartDate.
+	MonthlyPace float64 `json:"monthly_pace"`
+	// RequiredMonthly is the amount to save per month from now on to reach the target by the deadline.
+	RequiredMonthly float64 `json:"required_monthly"`
+	// ProjectedCompletion extrapolates MonthlyPace, null when nothing has been saved.
+	ProjectedCompletion *time.Time `json:"projected_completion"`
+	OnTrack             bool       `json:"on_track"`
+}
+
+type GoalReport struct {
+	Date  time.Time    `json:"date"`
+	Goals []GoalStatus `json:"goals"`
+	// Members break the goals down per user when the report covers several users.
+	Members []GoalMemberTotal `json:"members,omitempty"`
+}
+
+// GoalMemberTotal sums the goals of one user.
+type GoalMemberTotal struct {
+	UserUUID     string  `json:"user_uuid"`
+	TargetAmount float64 `json:"target_amount"`
+	Saved        float64 `json:"saved"`
+	Remaining    float64 `json:"remaining"`
+}
+
+// NewGoalStatus compares the amount saved from the start of the goal up to and including date
+// with the target. The deadline counts as a whole day.
+func NewGoalStatus(goal Goal, date time.Time, saved float64) GoalStatus {
+	status := GoalStatus{
+		Goal:            goal,
+		Saved:           saved,
+		Remaining:       goal.TargetAmount - saved,
+		PercentComplete: saved / goal.TargetAmount * 100,
+	}
+	if status.Remaining <= 0 {
+		status.Remaining = 0
+		status.Achieved = true
+		status.OnTrack = true
+		return status
+	}
+
+	elapsedMonths := (date.Sub(goal.StartDate).Hours()/24 + 1) / daysPerMonth
+	if elapsedMonths > 0 {
+		status.MonthlyPace = saved / elapsedMonths
+	}
+
+	leftMonths := goal.Deadline.AddDate(0, 0, 1).Sub(date).Hours() / 24 / daysPerMonth
+	if leftMonths < 1 {
+		// past or close to the deadline the whole remainder is due now
+		status.RequiredMonthly = status.Remaining
+	} else {
+		status.RequiredMonthly = status.Remaining / leftMonths
+	}
+
+	if status.MonthlyPace > 0 {
+		days := int(status.Remaining / status.MonthlyPace * daysPerMonth)
+		completion := date.AddDate(0, 0, days+1)
+		status.ProjectedCompletion = &completion
+		status.OnTrack = !completion.After(goal.Deadline)
+	}
+	return status
+}
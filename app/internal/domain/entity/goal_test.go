@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewGoalStatus(t *testing.T) {
+	goal := Goal{
+		TargetAmount: 1200,
+		StartDate:    time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC),
+		Deadline:     time.Date(2024, time.December, 31, 0, 0, 0, 0, time.UTC),
+	}
+	midYear := time.Date(2024, time.June, 30, 0, 0, 0, 0, time.UTC)
+	date := func(year int, month time.Month, day int) *time.Time {
+		t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+		return &t
+	}
+
+	tests := []struct {
+		name            string
+		date            time.Time
+		saved           float64
+		remaining       float64
+		percent         float64
+		achieved        bool
+		monthlyPace     float64
+		requiredMonthly float64
+		projected       *time.Time
+		onTrack         bool
+	}{
+		{name: "nothing saved on the first day", date: goal.StartDate, remaining: 1200,
+			requiredMonthly: 1200 / (366 / daysPerMonth)},
+		{name: "achieved", date: midYear, saved: 1200, percent: 100, achieved: true, onTrack: true},
+		{name: "over target", date: midYear, saved: 1500, percent: 125, achieved: true, onTrack: true},
+		{name: "on track", date: midYear, saved: 700, remaining: 500, percent: 700.0 / 12,
+			monthlyPace: 700 / (182 / daysPerMonth), requiredMonthly: 500 / (185 / daysPerMonth),
+			projected: date(2024, time.November, 8), onTrack: true},
+		{name: "behind", date: midYear, saved: 300, remaining: 900, percent: 25,
+			monthlyPace: 300 / (182 / daysPerMonth), requiredMonthly: 900 / (185 / daysPerMonth),
+			projected: date(2025, time.December, 29)},
+		{name: "past the deadline", date: time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC), saved: 600,
+			remaining: 600, percent: 50, monthlyPace: 600 / (381 / daysPerMonth), requiredMonthly: 600,
+			projected: date(2026, time.February, 1)},
+		{name: "savings lost", date: midYear, saved: -100, remaining: 1300, percent: -100.0 / 12,
+			monthlyPace: -100 / (182 / daysPerMonth), requiredMonthly: 1300 / (185 / daysPerMonth)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := NewGoalStatus(goal, tt.date, tt.saved)
+			check := func(name string, got, want float64) {
+				if math.Abs(got-want) > 1e-9 {
+					t.Errorf("%s = %v, want %v", name, got, want)
+				}
+			}
+			check("remaining", status.Remaining, tt.remaining)
+			check("percent complete", status.PercentComplete, tt.percent)
+			check("monthly pace", status.MonthlyPace, tt.monthlyPace)
+			check("required monthly", status.RequiredMonthly, tt.requiredMonthly)
+			if status.Achieved != tt.achieved {
+				t.Errorf("achieved = %v, want %v", status.Achieved, tt.achieved)
+			}
+			if status.OnTrack != tt.onTrack {
+				t.Errorf("on track = %v, want %v", status.OnTrack, tt.onTrack)
+			}
+			switch {
+			case tt.projected == nil && status.ProjectedCompletion != nil:
+				t.Errorf("projected completion = %v, want none", status.ProjectedCompletion)
+			case tt.projected != nil && (status.ProjectedCompletion == nil || !status.ProjectedCompletion.Equal(*tt.projected)):
+				t.Errorf("projected completion = %v, want %v", status.ProjectedCompletion, tt.projected)
+			}
+		})
+	}
+}
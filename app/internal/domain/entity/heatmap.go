@@ -0,0 +1,11 @@
+package entity
+
+// Heatmap holds the count and the sum of operations per day of week and hour in Timezone,
+// indexed as [weekday][hour] with Sunday as weekday 0.
+type Heatmap struct {
+	Timezone string         `json:"timezone"`
+	Counts   [7][24]int64   `json:"counts"`
+	Sums     [7][24]float64 `json:"sums"`
+	// Members break the totals down per user when the heatmap covers several users.
+	Members []MemberTotal `json:"members,omitempty"`
+}
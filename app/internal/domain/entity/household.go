@@ -0,0 +1,72 @@
+package entity
+
+type HouseholdRole string
+
+const (
+	// HouseholdRoleOwner can manage the members and delete the household.
+	HouseholdRoleOwner  HouseholdRole = "owner"
+	HouseholdRoleMember HouseholdRole = "member"
+)
+
+// HouseholdUUID is the query parameter scoping statistics to all members of a household.
+const HouseholdUUID = "household_uuid"
+
+// Household groups users whose operations can be reported together.
+type Household struct {
+	UUID    string            `json:"uuid"`
+	Name    string            `json:"name"`
+	Members []HouseholdMember `json:"members"`
+}
+
+// HouseholdMember is a user invited to the household by an owner. The user takes part in the
+// household only after accepting the invitation.
+type HouseholdMember struct {
+	UserUUID string        `json:"user_uuid"`
+	Role     HouseholdRole `json:"role"`
+	Accepted bool          `json:"accepted"`
+}
+
+type HouseholdDTO struct {
+	Name string `json:"name"`
+}
+
+type HouseholdMemberDTO struct {
+	Role HouseholdRole `json:"role"`
+}
+
+// Member returns the membership of userUUID, false when the user does not belong to the household
+// or has not accepted the invitation yet.
+func (h Household) Member(userUUID string) (HouseholdMember, bool) {
+	member, ok := h.Invitation(userUUID)
+	return member, ok && member.Accepted
+}
+
+// Invitation returns the membership of userUUID whether accepted or not.
+func (h Household) Invitation(userUUID string) (HouseholdMember, bool) {
+	for _, member := range h.Members {
+		if member.UserUUID == userUUID {
+			return member, true
+		}
+	}
+	return HouseholdMember{}, false
+}
+
+// MemberUUIDs lists the users who accepted their invitation.
+func (h Household) MemberUUIDs() []string {
+	users := make([]string, 0, len(h.Members))
+	for _, member := range h.Members {
+		if member.Accepted {
+			users = append(users, member.UserUUID)
+		}
+	}
+	return users
+}
+
+// MemberTotal breaks a report over several users down to a single user.
+type MemberTotal struct {
+	UserUUID string  `json:"user_uuid"`
+	Income   float64 `json:"income"`
+	Expense  float64 `json:"expense"`
+	Net      float64 `json:"net"`
+	Count    int64   `json:"count"`
+}
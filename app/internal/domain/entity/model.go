@@ -37,17 +37,45 @@ const (
 )
 
 type Report struct {
-	TotalMoneySum float64     `json:"total_money_sum"`
-	Operations    []Operation `json:"operations"`
+	TotalMoneySum float64      `json:"total_money_sum"`
+	Distribution  Distribution `json:"distribution"`
+	// Members is only set when the report covers several users.
+	Members    []MemberTotal `json:"members,omitempty"`
+	Operations []Operation   `json:"operations"`
 }
 
-func NewReport(operations []Operation) Report {
+func NewReport(operations []Operation, distribution Distribution) Report {
 	sum := 0.0
 	for _, op := range operations {
 		sum += op.MoneySum
 	}
 	return Report{
 		TotalMoneySum: sum,
+		Distribution:  distribution,
 		Operations:    operations,
 	}
 }
+
+type ReportVersion struct {
+	OperationsCount int64
+	LastDateTime    time.Time
+	RowVersion      int64
+}
+
+type SlowQuery struct {
+	ID         int64     `json:"id"`
+	SQL        string    `json:"sql"`
+	Args       []string  `json:"args"`
+	DurationMs float64   `json:"duration_ms"`
+	Rows       int64     `json:"rows"`
+	StartedAt  time.Time `json:"started_at"`
+	RequestID  string    `json:"request_id,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	Plan       string    `json:"plan,omitempty"`
+}
+
+type QueryPlan struct {
+	SQL  string   `json:"sql"`
+	Args []string `json:"args"`
+	Plan string   `json:"plan"`
+}
@@ -0,0 +1,43 @@
+package entity
+
+import "time"
+
+type RecurringPeriod string
+
+const (
+	RecurringWeekly  RecurringPeriod = "weekly"
+	RecurringMonthly RecurringPeriod = "monthly"
+	RecurringYearly  RecurringPeriod = "yearly"
+)
+
+// RecurringSeries is a group of operations of one category with a similar description and amount
+// that repeat at a regular interval, such as a subscription or rent.
+type RecurringSeries struct {
+	CategoryUUID     string          `json:"category_uuid"`
+	Description      string          `json:"description"`
+	Period           RecurringPeriod `json:"period"`
+	Occurrences      int             `json:"occurrences"`
+	TypicalAmount    float64         `json:"typical_amount"`
+	FirstDate        time.Time       `json:"first_date"`
+	LastDate         time.Time       `json:"last_date"`
+	NextExpectedDate time.Time       `json:"next_expected_date"`
+	Confidence       float64         `json:"confidence"`
+	// Active is false when the last expected occurrence has been missed.
+	Active bool `json:"active"`
+}
+
+type RecurringReport struct {
+	Series []RecurringSeries `json:"series"`
+}
+
+// NextAfter returns the date one period after date.
+func (p RecurringPeriod) NextAfter(date time.Time) time.Time {
+	switch p {
+	case RecurringWeekly:
+		return date.AddDate(0, 0, 7)
+	case RecurringYearly:
+		return date.AddDate(1, 0, 0)
+	default:
+		return date.AddDate(0, 1, 0)
+	}
+}
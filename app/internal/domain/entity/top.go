@@ -0,0 +1,32 @@
+package entity
+
+const (
+	TopByCategory    = "category"
+	TopByDescription = "description"
+	TopByOperation   = "operation"
+
+	TopMetricSum   = "sum"
+	TopMetricCount = "count"
+)
+
+// TopItem is a category, a normalized description or a single operation identified by Key.
+type TopItem struct {
+	Key   string  `json:"key"`
+	Label string  `json:"label"`
+	Total float64 `json:"total"`
+	Count int64   `json:"count"`
+	// Percent is the share of the ranking metric over all matching operations.
+	Percent float64 `json:"percent"`
+}
+
+type TopReport struct {
+	By     string    `json:"by"`
+	Metric string    `json:"metric"`
+	Total  float64   `json:"total"`
+	Count  int64     `json:"count"`
+	Items  []TopItem `json:"items"`
+	// Other aggregates everything outside Items, so the items and other add up to the totals.
+	Other TopItem `json:"other"`
+	// Members break the totals down per user when the report covers several users.
+	Members []MemberTotal `json:"members,omitempty"`
+}
@@ -0,0 +1,491 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"stats-service/internal/apperror"
+	"stats-service/internal/controller"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/tracing"
+	"stats-service/pkg/webhook"
+	"sync"
+	"time"
+)
+
+type Notifier interface {
+	Send(ctx context.Context, url, event string, payload interface{}) error
+}
+
+type alertService struct {
+	alerts  AlertRepository
+	budgets BudgetRepository
+	// allowPrivateNetworks accepts webhook urls on loopback and private hosts
+	allowPrivateNetworks bool
+	logger               *logging.Logger
+}
+
+func NewAlertService(alerts AlertRepository, budgets BudgetRepository, allowPrivateNetworks bool,
+	logger *logging.Logger) controller.AlertService {
+	return &alertService{
+		alerts:               alerts,
+		budgets:              budgets,
+		allowPrivateNetworks: allowPrivateNetworks,
+		logger:               logger,
+	}
+}
+
+func (s *alertService) Create(ctx context.Context, dto entity.AlertRuleDTO) (entity.AlertRule, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.CreateAlertRule")
+	defer span.Finish()
+
+	rule, err := s.newAlertRule(ctx, dto)
+	if err != nil {
+		span.RecordError(err)
+		return rule, err
+	}
+
+	rule.UUID, err = s.alerts.Create(ctx, rule)
+	if err != nil {
+		span.RecordError(err)
+		return rule, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (s *alertService) GetAll(ctx context.Context, userUUID string) ([]entity.AlertRule, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetAlertRules")
+	defer span.Finish()
+
+	rules, err := s.alerts.FindAll(ctx, userUUID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+func (s *alertService) GetOne(ctx context.Context, userUUID, uuid string) (entity.AlertRule, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetAlertRule")
+	defer span.Finish()
+
+	rule, err := s.owned(ctx, userUUID, uuid)
+	if err != nil {
+		span.RecordError(err)
+		return rule, err
+	}
+	return rule, nil
+}
+
+func (s *alertService) Update(ctx context.Context, uuid string, dto entity.AlertRuleDTO) (entity.AlertRule, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.UpdateAlertRule")
+	defer span.Finish()
+
+	if _, err := s.owned(ctx, dto.UserUUID, uuid); err != nil {
+		span.RecordError(err)
+		return entity.AlertRule{}, err
+	}
+
+	rule, err := s.newAlertRule(ctx, dto)
+	if err != nil {
+		span.RecordError(err)
+		return rule, err
+	}
+
+	// the repository drops the state when the definition changes, so the updated rule can fire again
+	rule.UUID = uuid
+	if err = s.alerts.Update(ctx, rule); err != nil {
+		span.RecordError(err)
+		return rule, fmt.Errorf("failed to update alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+func (s *alertService) Delete(ctx context.Context, userUUID, uuid string) error {
+	ctx, span := tracing.StartSpan(ctx, "service.DeleteAlertRule")
+	defer span.Finish()
+
+	if _, err := s.owned(ctx, userUUID, uuid); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if err := s.alerts.Delete(ctx, uuid); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to delete alert rule: %w", err)
+	}
+	return nil
+}
+
+// owned loads the alert rule uuid and checks that it belongs to userUUID.
+func (s *alertService) owned(ctx context.Context, userUUID, uuid string) (entity.AlertRule, error) {
+	rule, err := s.alerts.FindOne(ctx, uuid)
+	if err != nil {
+		return rule, fmt.Errorf("failed to get alert rule: %w", err)
+	}
+	if rule.UserUUID != userUUID {
+		return entity.AlertRule{}, apperror.ErrForbidden
+	}
+	return rule, nil
+}
+
+func (s *alertService) newAlertRule(ctx context.Context, dto entity.AlertRuleDTO) (entity.AlertRule, error) {
+	rule := entity.AlertRule{
+		UserUUID:     dto.UserUUID,
+		Kind:         dto.Kind,
+		CategoryUUID: dto.CategoryUUID,
+		CategoryType: dto.CategoryType,
+		BudgetUUID:   dto.BudgetUUID,
+		Period:       dto.Period,
+		Threshold:    dto.Threshold,
+		WebhookURL:   dto.WebhookURL,
+		Enabled:      dto.Enabled == nil || *dto.Enabled,
+	}
+
+	fields := make(apperror.ErrorFields)
+	if dto.UserUUID == "" {
+		fields["user_uuid"] = "is required"
+	}
+	if dto.Threshold <= 0 {
+		fields["threshold"] = "must be greater than zero"
+	}
+	if webhookURL, err := url.Parse(dto.WebhookURL); err != nil || webhookURL.Host == "" ||
+		(webhookURL.Scheme != "http" && webhookURL.Scheme != "https") {
+		fields["webhook_url"] = "must be an absolute http or https URL"
+	} else if err = webhook.ValidateURL(ctx, dto.WebhookURL, s.allowPrivateNetworks); errors.Is(err, webhook.ErrForbiddenAddress) {
+		fields["webhook_url"] = "must resolve to public addresses only"
+	} else if err != nil {
+		fields["webhook_url"] = "host cannot be resolved"
+	}
+
+	switch dto.Kind {
+	case entity.AlertKindBudgetPercent:
+		if dto.CategoryUUID != "" || dto.CategoryType != "" || dto.Period != "" {
+			fields["kind"] = "category and period are taken from the budget for budget_percent rules"
+		}
+		if dto.BudgetUUID == "" {
+			fields["budget_uuid"] = "is required for budget_percent rules"
+			break
+		}
+		budget, err := s.budgets.FindOne(ctx, dto.BudgetUUID)
+		if errors.Is(err, apperror.ErrNotFound) || (err == nil && budget.UserUUID != dto.UserUUID) {
+			fields["budget_uuid"] = "budget of the user not found"
+		} else if err != nil {
+			return rule, fmt.Errorf("failed to get budget: %w", err)
+		}
+	case entity.AlertKindThreshold, entity.AlertKindGrowth:
+		if dto.BudgetUUID != "" {
+			fields["budget_uuid"] = "is only allowed for budget_percent rules"
+		}
+		if dto.CategoryUUID != "" && dto.CategoryType != "" {
+			fields["category_uuid"] = "at most one of category_uuid and category_type is allowed"
+		}
+		if dto.CategoryType != "" && dto.CategoryType != entity.IncomeType && dto.CategoryType != entity.ExpenseType {
+			fields["category_type"] = fmt.Sprintf("must be %s or %s", entity.IncomeType, entity.ExpenseType)
+		}
+		if dto.Period != entity.BudgetPeriodMonthly && dto.Period != entity.BudgetPeriodWeekly {
+			fields["period"] = fmt.Sprintf("must be %s or %s", entity.BudgetPeriodMonthly, entity.BudgetPeriodWeekly)
+		}
+	default:
+		fields["kind"] = fmt.Sprintf("must be one of %s, %s, %s",
+			entity.AlertKindThreshold, entity.AlertKindBudgetPercent, entity.AlertKindGrowth)
+	}
+
+	if len(fields) > 0 {
+		validationErr := apperror.BadRequestError("alert rule validation failed")
+		validationErr.WithFields(fields)
+		return rule, validationErr
+	}
+	return rule, nil
+}
+
+// AlertEvaluatorOptions bound the webhook deliveries running next to the evaluation loop.
+type AlertEvaluatorOptions struct {
+	// Workers is the number of deliveries made concurrently.
+	Workers int
+	// QueueSize is the number of deliveries waiting for a worker; further ones are
+	// dropped and attempted again on a later evaluation.
+	QueueSize int
+}
+
+// AlertEvaluator periodically evaluates all enabled alert rules and delivers a notification
+// the first time a rule fires in a period. Deliveries, retries included, run on a bounded
+// pool of workers; the rule is marked as firing by the first evaluation after a successful
+// delivery, while a failed one leaves it not firing, so it is attempted again.
+type AlertEvaluator struct {
+	alerts     AlertRepository
+	budgets    BudgetRepository
+	operations Repository
+	notifier   Notifier
+	options    AlertEvaluatorOptions
+	logger     *logging.Logger
+
+	queue      chan alertDelivery
+	mu         sync.Mutex
+	deliveries map[string]*alertDeliveryResult
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+type alertDelivery struct {
+	url          string
+	notification entity.AlertNotification
+	result       *alertDeliveryResult
+}
+
+// alertDeliveryResult tracks the last delivery queued for a rule until an evaluation picks it up.
+type alertDeliveryResult struct {
+	periodStart time.Time
+	triggeredAt time.Time
+	done        bool
+	err         error
+}
+
+func NewAlertEvaluator(alerts AlertRepository, budgets BudgetRepository, operations Repository,
+	notifier Notifier, options AlertEvaluatorOptions, logger *logging.Logger) *AlertEvaluator {
+	if options.Workers < 1 {
+		options.Workers = 1
+	}
+	if options.QueueSize < 0 {
+		options.QueueSize = 0
+	}
+	return &AlertEvaluator{
+		alerts:     alerts,
+		budgets:    budgets,
+		operations: operations,
+		notifier:   notifier,
+		options:    options,
+		logger:     logger,
+		queue:      make(chan alertDelivery, options.QueueSize),
+		deliveries: make(map[string]*alertDeliveryResult),
+	}
+}
+
+func (e *AlertEvaluator) Start(interval time.Duration) {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+
+	for i := 0; i < e.options.Workers; i++ {
+		e.wg.Add(1)
+		go func() {
+			defer e.wg.Done()
+			e.deliver(ctx)
+		}()
+	}
+
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if err := e.Evaluate(ctx); err != nil && ctx.Err() == nil {
+				e.logger.Errorf("alert evaluation failed: %v", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Close stops the evaluation loop and the delivery workers, aborting pending webhook retries,
+// and waits for them to exit. Queued deliveries are dropped and attempted again after a restart.
+func (e *AlertEvaluator) Close() error {
+	if e.cancel != nil {
+		e.cancel()
+	}
+	e.wg.Wait()
+	return nil
+}
+
+// Evaluate runs a single pass over all enabled rules.
+func (e *AlertEvaluator) Evaluate(ctx context.Context) error {
+	ctx, span := tracing.StartSpan(ctx, "alerts.Evaluate")
+	defer span.Finish()
+
+	rules, err := e.alerts.FindEnabled(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to get alert rules: %w", err)
+	}
+	span.SetAttribute("alerts.rules", len(rules))
+
+	now := time.Now().UTC()
+	for _, rule := range rules {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err = e.evaluateRule(ctx, rule, now); err != nil {
+			e.logger.Errorf("failed to evaluate alert rule %s: %v", rule.UUID, err)
+		}
+	}
+	return nil
+}
+
+func (e *AlertEvaluator) evaluateRule(ctx context.Context, rule entity.AlertRule, now time.Time) error {
+	value, start, end, err := e.measure(ctx, rule, now)
+	if err != nil {
+		return err
+	}
+
+	state := entity.AlertState{
+		RuleUUID:    rule.UUID,
+		PeriodStart: start,
+		LastValue:   value,
+		EvaluatedAt: now,
+	}
+	notified := rule.State != nil && rule.State.Firing && rule.State.PeriodStart.Equal(start)
+
+	if value >= rule.Threshold {
+		triggeredAt, pending := e.deliveryResult(rule.UUID, start)
+		switch {
+		case notified:
+			state.Firing = true
+			state.TriggeredAt = rule.State.TriggeredAt
+		case triggeredAt != nil:
+			e.logger.Infof("alert rule %s triggered with value %.2f", rule.UUID, value)
+			state.Firing = true
+			state.TriggeredAt = triggeredAt
+		case !pending:
+			e.enqueue(rule, alertDelivery{
+				url: rule.WebhookURL,
+				notification: entity.AlertNotification{
+					Event:        entity.AlertEventTriggered,
+					RuleUUID:     rule.UUID,
+					UserUUID:     rule.UserUUID,
+					Kind:         rule.Kind,
+					CategoryUUID: rule.CategoryUUID,
+					CategoryType: rule.CategoryType,
+					BudgetUUID:   rule.BudgetUUID,
+					Threshold:    rule.Threshold,
+					Value:        value,
+					PeriodStart:  start,
+					PeriodEnd:    end.AddDate(0, 0, -1),
+					TriggeredAt:  now,
+				},
+				result: &alertDeliveryResult{periodStart: start, triggeredAt: now},
+			})
+		}
+	}
+
+	if err = e.alerts.SaveState(ctx, state); err != nil {
+		return fmt.Errorf("failed to save alert state: %w", err)
+	}
+	return nil
+}
+
+// deliveryResult reports the trigger time of a successful delivery for the rule in the period
+// starting at start, or whether a delivery is still in flight. Finished deliveries are forgotten.
+func (e *AlertEvaluator) deliveryResult(ruleUUID string, start time.Time) (*time.Time, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	result, ok := e.deliveries[ruleUUID]
+	if !ok {
+		return nil, false
+	}
+	if !result.done {
+		return nil, true
+	}
+	delete(e.deliveries, ruleUUID)
+	if result.err != nil || !result.periodStart.Equal(start) {
+		return nil, false
+	}
+	return &result.triggeredAt, false
+}
+
+func (e *AlertEvaluator) enqueue(rule entity.AlertRule, delivery alertDelivery) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	select {
+	case e.queue <- delivery:
+		e.deliveries[rule.UUID] = delivery.result
+	default:
+		e.logger.Warnf("alert delivery queue is full, rule %s is notified on a later evaluation", rule.UUID)
+	}
+}
+
+// deliver sends queued notifications until ctx is cancelled.
+func (e *AlertEvaluator) deliver(ctx context.Context) {
+	for {
+		select {
+		case delivery := <-e.queue:
+			err := e.notifier.Send(ctx, delivery.url, entity.AlertEventTriggered, delivery.notification)
+			if err != nil {
+				e.logger.Warnf("failed to notify about alert rule %s: %v", delivery.notification.RuleUUID, err)
+			}
+
+			e.mu.Lock()
+			delivery.result.done = true
+			delivery.result.err = err
+			e.mu.Unlock()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// measure computes the value compared with the rule threshold and the period it belongs to.
+func (e *AlertEvaluator) measure(ctx context.Context, rule entity.AlertRule, now time.Time) (float64, time.Time, time.Time, error) {
+	switch rule.Kind {
+	case entity.AlertKindBudgetPercent:
+		budget, err := e.budgets.FindOne(ctx, rule.BudgetUUID)
+		if err != nil {
+			return 0, time.Time{}, time.Time{}, fmt.Errorf("failed to get budget: %w", err)
+		}
+		start, end := budget.PeriodAt(now)
+		actual, err := e.operations.SumOperations(ctx, budgetScope(budget), start, end)
+		if err != nil {
+			return 0, start, end, err
+		}
+		return actual / budget.Amount * 100, start, end, nil
+
+	case entity.AlertKindGrowth:
+		// compare the elapsed part of the current period with the same number of days of the previous one
+		start, end := entity.PeriodBounds(rule.Period, now)
+		to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC).AddDate(0, 0, 1)
+		current, err := e.operations.SumOperations(ctx, alertScope(rule), start, to)
+		if err != nil {
+			return 0, start, end, err
+		}
+
+		previousStart, _ := entity.PeriodBounds(rule.Period, start.AddDate(0, 0, -1))
+		previousTo := previousStart.AddDate(0, 0, int(to.Sub(start).Hours()/24))
+		if previousTo.After(start) {
+			previousTo = start
+		}
+		previous, err := e.operations.SumOperations(ctx, alertScope(rule), previousStart, previousTo)
+		if err != nil {
+			return 0, start, end, err
+		}
+		if previous == 0 {
+			return 0, start, end, nil
+		}
+		return (current - previous) / previous * 100, start, end, nil
+
+	default:
+		start, end := entity.PeriodBounds(rule.Period, now)
+		actual, err := e.operations.SumOperations(ctx, alertScope(rule), start, end)
+		return actual, start, end, err
+	}
+}
+
+// alertScope defaults to all expenses of the user when the rule names no category.
+func alertScope(rule entity.AlertRule) entity.SpendingScope {
+	scope := entity.SpendingScope{
+		UserUUID:     rule.UserUUID,
+		CategoryUUID: rule.CategoryUUID,
+		CategoryType: rule.CategoryType,
+	}
+	if scope.CategoryUUID == "" && scope.CategoryType == "" {
+		scope.CategoryType = entity.ExpenseType
+	}
+	return scope
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"math"
+	"stats-service/internal/domain/analytics"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/api/filter"
+	"stats-service/pkg/tracing"
+	"time"
+)
+
+const (
+	// anomalyMinOperations is the number of earlier operations of a category needed to judge one
+	anomalyMinOperations = 5
+	anomalyMinDays       = 14
+	anomalyMinMonths     = 3
+	// months are compared with the year before them regardless of the window
+	anomalyMonthsWindow = 365 * 24 * time.Hour
+)
+
+func (s *service) GetAnomalies(ctx context.Context, filterOptions filter.Options, options entity.AnomalyOptions) (entity.AnomalyReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetAnomalies")
+	defer span.Finish()
+
+	report := entity.AnomalyReport{
+		WindowDays: options.WindowDays,
+		Threshold:  options.Threshold,
+	}
+	operations, err := s.findByDate(ctx, filterOptions)
+	if err != nil {
+		span.RecordError(err)
+		return report, err
+	}
+	window := time.Duration(options.WindowDays) * 24 * time.Hour
+
+	report.Operations = make([]entity.OperationAnomaly, 0)
+	byCategory := make(map[string][]int)
+	categories := make([]string, 0)
+	for i, op := range operations {
+		if _, ok := byCategory[op.CategoryUUID]; !ok {
+			categories = append(categories, op.CategoryUUID)
+		}
+		byCategory[op.CategoryUUID] = append(byCategory[op.CategoryUUID], i)
+	}
+	for _, category := range categories {
+		indexes := byCategory[category]
+		points := make([]analytics.Point, 0, len(indexes))
+		for _, i := range indexes {
+			points = append(points, analytics.Point{Time: operations[i].DateTime, Value: operations[i].MoneySum})
+		}
+		for _, outlier := range analytics.TrailingOutliers(points, window, anomalyMinOperations, options.Threshold) {
+			report.Operations = append(report.Operations, entity.OperationAnomaly{
+				Operation: operations[indexes[outlier.Index]],
+				Baseline:  anomalyBaseline(outlier.Baseline),
+				Deviation: outlier.Point.Value - outlier.Baseline.Median,
+				ZScore:    roundScore(outlier.ZScore),
+			})
+		}
+	}
+
+	points := make([]analytics.Point, 0, len(operations))
+	for _, op := range operations {
+		points = append(points, analytics.Point{Time: op.DateTime, Value: op.MoneySum})
+	}
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	report.Days = periodAnomalies(entity.AnomalyPeriodDay, withoutOpenShortfall(
+		analytics.TrailingOutliers(analytics.DailyTotals(points), window, anomalyMinDays, options.Threshold), today))
+	report.Months = periodAnomalies(entity.AnomalyPeriodMonth, withoutOpenShortfall(
+		analytics.TrailingOutliers(analytics.MonthlyTotals(points), anomalyMonthsWindow, anomalyMinMonths, options.Threshold),
+		today.AddDate(0, 0, 1-today.Day())))
+
+	if report.Members, err = s.members(ctx, filterOptions); err != nil {
+		span.RecordError(err)
+		return report, err
+	}
+
+	span.SetAttribute("report.operations", len(operations))
+	span.SetAttribute("report.anomalies", len(report.Operations)+len(report.Days)+len(report.Months))
+	return report, nil
+}
+
+// withoutOpenShortfall drops the negative outliers of the period starting at open, which is still
+// in progress: its total keeps growing until the period ends, so being low is no anomaly yet.
+func withoutOpenShortfall(outliers []analytics.Outlier, open time.Time) []analytics.Outlier {
+	kept := outliers[:0]
+	for _, outlier := range outliers {
+		if outlier.ZScore < 0 && !outlier.Point.Time.Before(open) {
+			continue
+		}
+		kept = append(kept, outlier)
+	}
+	return kept
+}
+
+func periodAnomalies(period string, outliers []analytics.Outlier) []entity.PeriodAnomaly {
+	anomalies := make([]entity.PeriodAnomaly, 0, len(outliers))
+	for _, outlier := range outliers {
+		anomalies = append(anomalies, entity.PeriodAnomaly{
+			Period:    period,
+			Start:     outlier.Point.Time,
+			Total:     outlier.Point.Value,
+			Baseline:  anomalyBaseline(outlier.Baseline),
+			Deviation: outlier.Point.Value - outlier.Baseline.Median,
+			ZScore:    roundScore(outlier.ZScore),
+		})
+	}
+	return anomalies
+}
+
+func anomalyBaseline(baseline analytics.Baseline) entity.AnomalyBaseline {
+	return entity.AnomalyBaseline{
+		Median: baseline.Median,
+		MAD:    baseline.MAD,
+		Size:   baseline.Size,
+	}
+}
+
+func roundScore(score float64) float64 {
+	return math.Round(score*100) / 100
+}
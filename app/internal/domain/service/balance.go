@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/api/filter"
+	"stats-service/pkg/tracing"
+)
+
+// GetBalance returns the running balance at the end of every interval from the first to the last
+// operation matching filterOptions, starting from openingBalance. Intervals without operations
+// carry the previous balance.
+func (s *service) GetBalance(ctx context.Context, filterOptions filter.Options, interval string, openingBalance float64) (entity.BalanceReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetBalance")
+	defer span.Finish()
+
+	report := entity.BalanceReport{
+		Interval:       interval,
+		OpeningBalance: openingBalance,
+		ClosingBalance: openingBalance,
+		Points:         make([]entity.BalancePoint, 0),
+	}
+	points, err := s.repository.FindBalance(ctx, filterOptions, interval)
+	if err != nil {
+		span.RecordError(err)
+		return report, fmt.Errorf("failed to get balance: %w", err)
+	}
+
+	for _, point := range points {
+		point.Balance += openingBalance
+		if len(report.Points) > 0 {
+			previous := report.Points[len(report.Points)-1]
+			for date := entity.NextInterval(interval, previous.Date); date.Before(point.Date); date = entity.NextInterval(interval, date) {
+				report.Points = append(report.Points, entity.BalancePoint{Date: date, Balance: previous.Balance})
+			}
+		}
+		report.Points = append(report.Points, point)
+		report.ClosingBalance = point.Balance
+	}
+	if report.Members, err = s.members(ctx, filterOptions); err != nil {
+		span.RecordError(err)
+		return report, err
+	}
+
+	span.SetAttribute("report.points", len(report.Points))
+	return report, nil
+}
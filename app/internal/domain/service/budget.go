@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stats-service/internal/apperror"
+	"stats-service/internal/controller"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/tracing"
+	"time"
+)
+
+type budgetService struct {
+	budgets    BudgetRepository
+	operations Repository
+	logger     *logging.Logger
+}
+
+func NewBudgetService(budgets BudgetRepository, operations Repository, logger *logging.Logger) controller.BudgetService {
+	return &budgetService{
+		budgets:    budgets,
+		operations: operations,
+		logger:     logger,
+	}
+}
+
+func (s *budgetService) Create(ctx context.Context, dto entity.BudgetDTO) (entity.Budget, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.CreateBudget")
+	defer span.Finish()
+
+	budget, err := s.newBudget(ctx, dto)
+	if err != nil {
+		span.RecordError(err)
+		return budget, err
+	}
+
+	budget.UUID, err = s.budgets.Create(ctx, budget)
+	if err != nil {
+		span.RecordError(err)
+		return budget, fmt.Errorf("failed to create budget: %w", err)
+	}
+	return budget, nil
+}
+
+func (s *budgetService) GetAll(ctx context.Context, userUUID string) ([]entity.Budget, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetBudgets")
+	defer span.Finish()
+
+	budgets, err := s.budgets.FindAll(ctx, userUUID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	return budgets, nil
+}
+
+func (s *budgetService) GetOne(ctx context.Context, userUUID, uuid string) (entity.Budget, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetBudget")
+	defer span.Finish()
+
+	budget, err := s.owned(ctx, userUUID, uuid)
+	if err != nil {
+		span.RecordError(err)
+		return budget, err
+	}
+	return budget, nil
+}
+
+func (s *budgetService) Update(ctx context.Context, uuid string, dto entity.BudgetDTO) (entity.Budget, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.UpdateBudget")
+	defer span.Finish()
+
+	if _, err := s.owned(ctx, dto.UserUUID, uuid); err != nil {
+		span.RecordError(err)
+		return entity.Budget{}, err
+	}
+
+	budget, err := s.newBudget(ctx, dto)
+	if err != nil {
+		span.RecordError(err)
+		return budget, err
+	}
+
+	budget.UUID = uuid
+	if err = s.budgets.Update(ctx, budget); err != nil {
+		span.RecordError(err)
+		return budget, fmt.Errorf("failed to update budget: %w", err)
+	}
+	return budget, nil
+}
+
+func (s *budgetService) Delete(ctx context.Context, userUUID, uuid string) error {
+	ctx, span := tracing.StartSpan(ctx, "service.DeleteBudget")
+	defer span.Finish()
+
+	if _, err := s.owned(ctx, userUUID, uuid); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if err := s.budgets.Delete(ctx, uuid); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to delete budget: %w", err)
+	}
+	return nil
+}
+
+// owned loads the budget uuid and checks that it belongs to userUUID.
+func (s *budgetService) owned(ctx context.Context, userUUID, uuid string) (entity.Budget, error) {
+	budget, err := s.budgets.FindOne(ctx, uuid)
+	if err != nil {
+		return budget, fmt.Errorf("failed to get budget: %w", err)
+	}
+	if budget.UserUUID != userUUID {
+		return entity.Budget{}, apperror.ErrForbidden
+	}
+	return budget, nil
+}
+
+// GetReport reports on the budgets of every user in userUUIDs, e.g. the members of a household,
+// with a total per user when there are several.
+func (s *budgetService) GetReport(ctx context.Context, userUUIDs []string, date time.Time) (entity.BudgetReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetBudgetReport")
+	defer span.Finish()
+
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	report := entity.BudgetReport{Date: date, Budgets: []entity.BudgetStatus{}}
+
+	for _, userUUID := range userUUIDs {
+		budgets, err := s.budgets.FindAll(ctx, userUUID)
+		if err != nil {
+			span.RecordError(err)
+			return report, fmt.Errorf("failed to get budgets: %w", err)
+		}
+
+		member := entity.BudgetMemberTotal{UserUUID: userUUID}
+		for _, budget := range budgets {
+			start, end := budget.PeriodAt(date)
+			actual, err := s.operations.SumOperations(ctx, budgetScope(budget), start, end)
+			if err != nil {
+				span.RecordError(err)
+				return report, fmt.Errorf("failed to get spending for budget %s: %w", budget.UUID, err)
+			}
+			status := entity.NewBudgetStatus(budget, date, start, end, actual)
+			report.Budgets = append(report.Budgets, status)
+			member.Amount += budget.Amount
+			member.Actual += status.Actual
+			member.Remaining += status.Remaining
+		}
+		if len(userUUIDs) > 1 {
+			report.Members = append(report.Members, member)
+		}
+	}
+
+	span.SetAttribute("report.budgets", len(report.Budgets))
+	return report, nil
+}
+
+func budgetScope(budget entity.Budget) entity.SpendingScope {
+	return entity.SpendingScope{
+		UserUUID:     budget.UserUUID,
+		CategoryUUID: budget.CategoryUUID,
+		CategoryType: budget.CategoryType,
+	}
+}
+
+func (s *budgetService) newBudget(ctx context.Context, dto entity.BudgetDTO) (entity.Budget, error) {
+	budget := entity.Budget{
+		UserUUID:     dto.UserUUID,
+		CategoryUUID: dto.CategoryUUID,
+		CategoryType: dto.CategoryType,
+		Amount:       dto.Amount,
+		Period:       dto.Period,
+	}
+
+	fields := make(apperror.ErrorFields)
+	if dto.UserUUID == "" {
+		fields["user_uuid"] = "is required"
+	}
+	if (dto.CategoryUUID == "") == (dto.CategoryType == "") {
+		fields["category_uuid"] = "exactly one of category_uuid and category_type is required"
+	} else if dto.CategoryUUID != "" {
+		category, err := s.operations.FindCategory(ctx, dto.CategoryUUID)
+		if errors.Is(err, apperror.ErrNotFound) || (err == nil && category.UserUUID != dto.UserUUID) {
+			fields["category_uuid"] = "category of the user not found"
+		} else if err != nil {
+			return budget, fmt.Errorf("failed to get category: %w", err)
+		}
+	}
+	if dto.CategoryType != "" && dto.CategoryType != entity.IncomeType && dto.CategoryType != entity.ExpenseType {
+		fields["category_type"] = fmt.Sprintf("must be %s or %s", entity.IncomeType, entity.ExpenseType)
+	}
+	if dto.Amount <= 0 {
+		fields["amount"] = "must be greater than zero"
+	}
+
+	switch dto.Period {
+	case entity.BudgetPeriodMonthly, entity.BudgetPeriodWeekly:
+		if dto.StartDate != "" || dto.EndDate != "" {
+			fields["period"] = "start_date and end_date are only allowed for a custom period"
+		}
+	case entity.BudgetPeriodCustom:
+		startDate, startErr := time.Parse(entity.DateLayout, dto.StartDate)
+		if startErr != nil {
+			fields["start_date"] = "must be a date in yyyy-mm-dd format"
+		}
+		endDate, endErr := time.Parse(entity.DateLayout, dto.EndDate)
+		if endErr != nil {
+			fields["end_date"] = "must be a date in yyyy-mm-dd format"
+		}
+		if startErr == nil && endErr == nil {
+			if endDate.Before(startDate) {
+				fields["end_date"] = "must not be before start_date"
+			}
+			budget.StartDate = &startDate
+			budget.EndDate = &endDate
+		}
+	default:
+		fields["period"] = fmt.Sprintf("must be one of %s, %s, %s",
+			entity.BudgetPeriodMonthly, entity.BudgetPeriodWeekly, entity.BudgetPeriodCustom)
+	}
+
+	if len(fields) > 0 {
+		validationErr := apperror.BadRequestError("budget validation failed")
+		validationErr.WithFields(fields)
+		return budget, validationErr
+	}
+	return budget, nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/api/filter"
+	"stats-service/pkg/tracing"
+)
+
+const comparisonTopContributors = 5
+
+func (s *service) GetComparison(ctx context.Context, filterOptions filter.Options, current, previous entity.DateRange) (entity.ComparisonReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetComparison")
+	defer span.Finish()
+
+	report := entity.ComparisonReport{
+		Current:  current,
+		Previous: previous,
+	}
+	currentTotals, err := s.repository.SumByCategory(ctx, filterOptions, current.From, current.End())
+	if err != nil {
+		span.RecordError(err)
+		return report, fmt.Errorf("failed to get totals of the current period: %w", err)
+	}
+	previousTotals, err := s.repository.SumByCategory(ctx, filterOptions, previous.From, previous.End())
+	if err != nil {
+		span.RecordError(err)
+		return report, fmt.Errorf("failed to get totals of the previous period: %w", err)
+	}
+
+	categories := make(map[string]*entity.CategoryComparison)
+	order := make([]string, 0, len(currentTotals))
+	category := func(total entity.CategoryTotal) *entity.CategoryComparison {
+		comparison, ok := categories[total.CategoryUUID]
+		if !ok {
+			comparison = &entity.CategoryComparison{
+				CategoryUUID: total.CategoryUUID,
+				CategoryName: total.CategoryName,
+				CategoryType: total.CategoryType,
+			}
+			categories[total.CategoryUUID] = comparison
+			order = append(order, total.CategoryUUID)
+		}
+		return comparison
+	}
+	for _, total := range currentTotals {
+		comparison := category(total)
+		comparison.Total.Current = total.Total
+		comparison.CurrentCount = total.Count
+	}
+	for _, total := range previousTotals {
+		comparison := category(total)
+		comparison.Total.Previous = total.Total
+		comparison.PreviousCount = total.Count
+	}
+
+	typeTotals := map[entity.CategoryType]*[2]float64{
+		entity.IncomeType:  {},
+		entity.ExpenseType: {},
+	}
+	var currentSum, previousSum float64
+	for _, comparison := range categories {
+		currentSum += comparison.Total.Current
+		previousSum += comparison.Total.Previous
+		if totals, ok := typeTotals[comparison.CategoryType]; ok {
+			totals[0] += comparison.Total.Current
+			totals[1] += comparison.Total.Previous
+		}
+	}
+	report.Total = entity.NewComparisonValue(currentSum, previousSum)
+	report.Income = entity.NewComparisonValue(typeTotals[entity.IncomeType][0], typeTotals[entity.IncomeType][1])
+	report.Expense = entity.NewComparisonValue(typeTotals[entity.ExpenseType][0], typeTotals[entity.ExpenseType][1])
+
+	report.Categories = make([]entity.CategoryComparison, 0, len(order))
+	for _, uuid := range order {
+		comparison := categories[uuid]
+		comparison.Total = entity.NewComparisonValue(comparison.Total.Current, comparison.Total.Previous)
+		if totals, ok := typeTotals[comparison.CategoryType]; ok && totals[0] != totals[1] {
+			comparison.Contribution = comparison.Total.Delta / (totals[0] - totals[1]) * 100
+		}
+		report.Categories = append(report.Categories, *comparison)
+	}
+	sort.SliceStable(report.Categories, func(i, j int) bool {
+		return math.Abs(report.Categories[i].Total.Delta) > math.Abs(report.Categories[j].Total.Delta)
+	})
+
+	report.TopContributors = make([]entity.CategoryComparison, 0, comparisonTopContributors)
+	for _, comparison := range report.Categories {
+		if len(report.TopContributors) == comparisonTopContributors || comparison.Total.Delta == 0 {
+			break
+		}
+		report.TopContributors = append(report.TopContributors, comparison)
+	}
+	if users := filterUsers(filterOptions); len(users) > 1 {
+		report.Members = memberComparisons(users, currentTotals, previousTotals)
+	}
+
+	span.SetAttribute("report.categories", len(report.Categories))
+	return report, nil
+}
+
+// memberComparisons adds the category totals of both periods up per user. Categories belong to a
+// single user, so no further query is needed; users without operations compare zeros.
+func memberComparisons(users []string, currentTotals, previousTotals []entity.CategoryTotal) []entity.MemberComparison {
+	type periodTotals struct {
+		income, expense [2]float64
+	}
+	byUser := make(map[string]*periodTotals, len(users))
+	for _, user := range users {
+		byUser[user] = &periodTotals{}
+	}
+	for period, totals := range [2][]entity.CategoryTotal{currentTotals, previousTotals} {
+		for _, total := range totals {
+			sums, ok := byUser[total.UserUUID]
+			if !ok {
+				continue
+			}
+			switch total.CategoryType {
+			case entity.IncomeType:
+				sums.income[period] += total.Total
+			case entity.ExpenseType:
+				sums.expense[period] += total.Total
+			}
+		}
+	}
+
+	members := make([]entity.MemberComparison, 0, len(users))
+	for _, user := range users {
+		sums := byUser[user]
+		members = append(members, entity.MemberComparison{
+			UserUUID: user,
+			Income:   entity.NewComparisonValue(sums.income[0], sums.income[1]),
+			Expense:  entity.NewComparisonValue(sums.expense[0], sums.expense[1]),
+			Net: entity.NewComparisonValue(sums.income[0]-sums.expense[0],
+				sums.income[1]-sums.expense[1]),
+		})
+	}
+	return members
+}
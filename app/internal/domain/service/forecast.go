@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"stats-service/internal/domain/analytics"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/api/filter"
+	"stats-service/pkg/tracing"
+	"time"
+)
+
+const (
+	forecastIntervalLevel = 0.8
+	// forecastIntervalZ is the standard normal quantile for a two-sided 80% interval
+	forecastIntervalZ = 1.2816
+)
+
+// GetForecast projects daily income and expense for horizonDays days starting tomorrow.
+// Recurring series are projected at their expected dates; all other operations are modelled
+// by their average per day scaled by weekday and month of year factors. The bounds assume
+// the daily deviations from the model are independent.
+func (s *service) GetForecast(ctx context.Context, filterOptions filter.Options, horizonDays int) (entity.ForecastReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetForecast")
+	defer span.Finish()
+
+	report := entity.ForecastReport{
+		HorizonDays:   horizonDays,
+		IntervalLevel: forecastIntervalLevel,
+		Recurring:     make([]entity.RecurringSeries, 0),
+		Days:          make([]entity.ForecastDay, 0, horizonDays),
+	}
+	operations, err := s.repository.FindAllWithType(ctx, filterOptions)
+	if err != nil {
+		span.RecordError(err)
+		return report, fmt.Errorf("failed to get operations: %w", err)
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from := today.AddDate(0, 0, 1)
+	to := from.AddDate(0, 0, horizonDays)
+
+	byType := map[entity.CategoryType][]entity.Operation{}
+	for _, op := range operations {
+		byType[op.CategoryType] = append(byType[op.CategoryType], op.Operation)
+	}
+
+	income := newForecastModel(byType[entity.IncomeType], today, now)
+	expense := newForecastModel(byType[entity.ExpenseType], today, now)
+	report.Recurring = append(income.recurring, expense.recurring...)
+	if len(operations) > 0 {
+		report.HistoryDays = int(today.Sub(truncateDay(operations[0].DateTime)).Hours() / 24)
+	}
+
+	incomeRecurring := income.project(from, to)
+	expenseRecurring := expense.project(from, to)
+	cumulative, cumulativeVariance := 0.0, 0.0
+	for date := from; date.Before(to); date = date.AddDate(0, 0, 1) {
+		incomeValue := income.seasonal.Predict(date) + incomeRecurring[date]
+		expenseValue := expense.seasonal.Predict(date) + expenseRecurring[date]
+		net := incomeValue - expenseValue
+		netSD := math.Hypot(income.seasonal.ResidualSD, expense.seasonal.ResidualSD)
+		cumulative += net
+		cumulativeVariance += netSD * netSD
+
+		report.Days = append(report.Days, entity.ForecastDay{
+			Date:          date,
+			Income:        forecastValue(incomeValue, income.seasonal.ResidualSD, true),
+			Expense:       forecastValue(expenseValue, expense.seasonal.ResidualSD, true),
+			Net:           forecastValue(net, netSD, false),
+			CumulativeNet: forecastValue(cumulative, math.Sqrt(cumulativeVariance), false),
+		})
+	}
+	if report.Members, err = s.members(ctx, filterOptions); err != nil {
+		span.RecordError(err)
+		return report, err
+	}
+
+	span.SetAttribute("report.operations", len(operations))
+	span.SetAttribute("report.recurring", len(report.Recurring))
+	return report, nil
+}
+
+type forecastModel struct {
+	seasonal  analytics.Seasonal
+	recurring []entity.RecurringSeries
+}
+
+// newForecastModel splits operations of one category type into the active recurring series and the
+// rest, and fits the seasonal model to the daily totals of the rest up to the day before today.
+func newForecastModel(operations []entity.Operation, today, now time.Time) forecastModel {
+	model := forecastModel{recurring: make([]entity.RecurringSeries, 0)}
+	if len(operations) == 0 {
+		model.seasonal = analytics.FitSeasonal(nil)
+		return model
+	}
+
+	recurringOperations := make(map[string]bool)
+	for _, match := range findRecurring(operations, now) {
+		for _, op := range match.operations {
+			recurringOperations[op.UUID] = true
+		}
+		if match.series.Active {
+			model.recurring = append(model.recurring, match.series)
+		}
+	}
+
+	points := make([]analytics.Point, 0, len(operations))
+	for _, op := range operations {
+		if !recurringOperations[op.UUID] {
+			points = append(points, analytics.Point{Time: op.DateTime, Value: op.MoneySum})
+		}
+	}
+
+	yesterday := today.AddDate(0, 0, -1)
+	first := truncateDay(operations[0].DateTime)
+	if first.After(yesterday) {
+		model.seasonal = analytics.FitSeasonal(nil)
+		return model
+	}
+	model.seasonal = analytics.FitSeasonal(analytics.DailyTotalsBetween(points, first, yesterday))
+	return model
+}
+
+// project returns the amounts of the recurring series expected on each day in [from, to).
+// An occurrence that is due but has not happened yet is expected on from.
+func (m forecastModel) project(from, to time.Time) map[time.Time]float64 {
+	amounts := make(map[time.Time]float64)
+	for _, series := range m.recurring {
+		for next := series.NextExpectedDate; next.Before(to); next = series.Period.NextAfter(next) {
+			date := truncateDay(next)
+			if date.Before(from) {
+				if series.Period.NextAfter(next).Before(from) {
+					continue
+				}
+				date = from
+			}
+			amounts[date] += series.TypicalAmount
+		}
+	}
+	return amounts
+}
+
+func forecastValue(value, sd float64, nonNegative bool) entity.ForecastValue {
+	lower := value - forecastIntervalZ*sd
+	if nonNegative && lower < 0 {
+		lower = 0
+	}
+	return entity.ForecastValue{
+		Value: roundMoney(value),
+		Lower: roundMoney(lower),
+		Upper: roundMoney(value + forecastIntervalZ*sd),
+	}
+}
+
+func truncateDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func roundMoney(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stats-service/internal/apperror"
+	"stats-service/internal/controller"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/tracing"
+	"strings"
+	"time"
+)
+
+type goalService struct {
+	goals      GoalRepository
+	operations Repository
+	logger     *logging.Logger
+}
+
+func NewGoalService(goals GoalRepository, operations Repository, logger *logging.Logger) controller.GoalService {
+	return &goalService{
+		goals:      goals,
+		operations: operations,
+		logger:     logger,
+	}
+}
+
+func (s *goalService) Create(ctx context.Context, dto entity.GoalDTO) (entity.Goal, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.CreateGoal")
+	defer span.Finish()
+
+	goal, err := s.newGoal(ctx, dto, time.Now())
+	if err != nil {
+		span.RecordError(err)
+		return goal, err
+	}
+
+	goal.UUID, err = s.goals.Create(ctx, goal)
+	if err != nil {
+		span.RecordError(err)
+		return goal, fmt.Errorf("failed to create goal: %w", err)
+	}
+	return goal, nil
+}
+
+func (s *goalService) GetAll(ctx context.Context, userUUID string) ([]entity.Goal, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetGoals")
+	defer span.Finish()
+
+	goals, err := s.goals.FindAll(ctx, userUUID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get goals: %w", err)
+	}
+	return goals, nil
+}
+
+func (s *goalService) GetOne(ctx context.Context, userUUID, uuid string) (entity.Goal, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetGoal")
+	defer span.Finish()
+
+	goal, err := s.owned(ctx, userUUID, uuid)
+	if err != nil {
+		span.RecordError(err)
+		return goal, err
+	}
+	return goal, nil
+}
+
+func (s *goalService) Update(ctx context.Context, uuid string, dto entity.GoalDTO) (entity.Goal, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.UpdateGoal")
+	defer span.Finish()
+
+	stored, err := s.owned(ctx, dto.UserUUID, uuid)
+	if err != nil {
+		span.RecordError(err)
+		return stored, err
+	}
+
+	// the goal keeps its start date unless a new one is given
+	goal, err := s.newGoal(ctx, dto, stored.StartDate)
+	if err != nil {
+		span.RecordError(err)
+		return goal, err
+	}
+
+	goal.UUID = uuid
+	if err = s.goals.Update(ctx, goal); err != nil {
+		span.RecordError(err)
+		return goal, fmt.Errorf("failed to update goal: %w", err)
+	}
+	return goal, nil
+}
+
+func (s *goalService) Delete(ctx context.Context, userUUID, uuid string) error {
+	ctx, span := tracing.StartSpan(ctx, "service.DeleteGoal")
+	defer span.Finish()
+
+	if _, err := s.owned(ctx, userUUID, uuid); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if err := s.goals.Delete(ctx, uuid); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+	return nil
+}
+
+// owned loads the goal uuid and checks that it belongs to userUUID.
+func (s *goalService) owned(ctx context.Context, userUUID, uuid string) (entity.Goal, error) {
+	goal, err := s.goals.FindOne(ctx, uuid)
+	if err != nil {
+		return goal, fmt.Errorf("failed to get goal: %w", err)
+	}
+	if goal.UserUUID != userUUID {
+		return entity.Goal{}, apperror.ErrForbidden
+	}
+	return goal, nil
+}
+
+// GetReport reports on the goals of every user in userUUIDs, e.g. the members of a household,
+// with a total per user when there are several.
+func (s *goalService) GetReport(ctx context.Context, userUUIDs []string, date time.Time) (entity.GoalReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetGoalReport")
+	defer span.Finish()
+
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	report := entity.GoalReport{Date: date, Goals: []entity.GoalStatus{}}
+
+	for _, userUUID := range userUUIDs {
+		goals, err := s.goals.FindAll(ctx, userUUID)
+		if err != nil {
+			span.RecordError(err)
+			return report, fmt.Errorf("failed to get goals: %w", err)
+		}
+
+		member := entity.GoalMemberTotal{UserUUID: userUUID}
+		for _, goal := range goals {
+			saved, err := s.saved(ctx, goal, date)
+			if err != nil {
+				span.RecordError(err)
+				return report, fmt.Errorf("failed to get savings for goal %s: %w", goal.UUID, err)
+			}
+			status := entity.NewGoalStatus(goal, date, saved)
+			report.Goals = append(report.Goals, status)
+			member.TargetAmount += goal.TargetAmount
+			member.Saved += status.Saved
+			member.Remaining += status.Remaining
+		}
+		if len(userUUIDs) > 1 {
+			report.Members = append(report.Members, member)
+		}
+	}
+
+	span.SetAttribute("report.goals", len(report.Goals))
+	return report, nil
+}
+
+// saved sums the operations counting towards goal from its start up to and including date.
+func (s *goalService) saved(ctx context.Context, goal entity.Goal, date time.Time) (float64, error) {
+	from, to := goal.StartDate, date.AddDate(0, 0, 1)
+	if !to.After(from) {
+		return 0, nil
+	}
+
+	income, err := s.operations.SumOperations(ctx, goalScope(goal, entity.IncomeType), from, to)
+	if err != nil || goal.Rule == entity.GoalRuleIncome {
+		return income, err
+	}
+	expense, err := s.operations.SumOperations(ctx, goalScope(goal, entity.ExpenseType), from, to)
+	if err != nil {
+		return 0, err
+	}
+	return income - expense, nil
+}
+
+func goalScope(goal entity.Goal, categoryType entity.CategoryType) entity.SpendingScope {
+	if goal.CategoryUUID != "" {
+		return entity.SpendingScope{UserUUID: goal.UserUUID, CategoryUUID: goal.CategoryUUID}
+	}
+	return entity.SpendingScope{UserUUID: goal.UserUUID, CategoryType: categoryType}
+}
+
+// newGoal validates dto. A goal without a start date starts on the day of defaultStart.
+func (s *goalService) newGoal(ctx context.Context, dto entity.GoalDTO, defaultStart time.Time) (entity.Goal, error) {
+	goal := entity.Goal{
+		UserUUID:     dto.UserUUID,
+		Name:         strings.TrimSpace(dto.Name),
+		TargetAmount: dto.TargetAmount,
+		Rule:         dto.Rule,
+		CategoryUUID: dto.CategoryUUID,
+		StartDate:    time.Date(defaultStart.Year(), defaultStart.Month(), defaultStart.Day(), 0, 0, 0, 0, time.UTC),
+	}
+	if goal.Rule == "" {
+		goal.Rule = entity.GoalRuleNetSavings
+	}
+
+	fields := make(apperror.ErrorFields)
+	if dto.UserUUID == "" {
+		fields["user_uuid"] = "is required"
+	}
+	if goal.Name == "" {
+		fields["name"] = "is required"
+	}
+	if dto.TargetAmount <= 0 {
+		fields["target_amount"] = "must be greater than zero"
+	}
+
+	switch goal.Rule {
+	case entity.GoalRuleIncome:
+		if dto.CategoryUUID == "" {
+			break
+		}
+		category, err := s.operations.FindCategory(ctx, dto.CategoryUUID)
+		if errors.Is(err, apperror.ErrNotFound) || (err == nil && category.UserUUID != dto.UserUUID) {
+			fields["category_uuid"] = "category of the user not found"
+		} else if err != nil {
+			return goal, fmt.Errorf("failed to get category: %w", err)
+		} else if category.Type != entity.IncomeType {
+			fields["category_uuid"] = fmt.Sprintf("must be an %s category", entity.IncomeType)
+		}
+	case entity.GoalRuleNetSavings:
+		if dto.CategoryUUID != "" {
+			fields["category_uuid"] = fmt.Sprintf("is only allowed for the %s rule", entity.GoalRuleIncome)
+		}
+	default:
+		fields["rule"] = fmt.Sprintf("must be one of %s, %s", entity.GoalRuleIncome, entity.GoalRuleNetSavings)
+	}
+
+	var err error
+	if dto.StartDate != "" {
+		if goal.StartDate, err = time.Parse(entity.DateLayout, dto.StartDate); err != nil {
+			fields["start_date"] = "must be a date in yyyy-mm-dd format"
+		}
+	}
+	if goal.Deadline, err = time.Parse(entity.DateLayout, dto.Deadline); err != nil {
+		fields["deadline"] = "must be a date in yyyy-mm-dd format"
+	} else if goal.Deadline.Before(goal.StartDate) {
+		fields["deadline"] = "must not be before start_date"
+	}
+
+	if len(fields) > 0 {
+		validationErr := apperror.BadRequestError("goal validation failed")
+		validationErr.WithFields(fields)
+		return goal, validationErr
+	}
+	return goal, nil
+}
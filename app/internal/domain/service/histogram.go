@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"stats-service/internal/apperror"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/api/filter"
+	"stats-service/pkg/tracing"
+)
+
+// GetHistogram splits the range between the smallest and the largest money sum of the operations
+// matching filterOptions into buckets of equal width, or of equal ratio for the logarithmic scale.
+func (s *service) GetHistogram(ctx context.Context, filterOptions filter.Options, buckets int, scale string) (entity.Histogram, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetHistogram")
+	defer span.Finish()
+	span.SetAttribute("histogram.scale", scale)
+
+	histogram := entity.Histogram{Scale: scale, Buckets: make([]entity.HistogramBucket, 0)}
+	distribution, err := s.repository.FindDistribution(ctx, filterOptions)
+	if err != nil {
+		span.RecordError(err)
+		return histogram, fmt.Errorf("failed to get operations distribution: %w", err)
+	}
+	histogram.Distribution = distribution
+	if histogram.Members, err = s.members(ctx, filterOptions); err != nil {
+		span.RecordError(err)
+		return histogram, err
+	}
+	if distribution.Count == 0 {
+		return histogram, nil
+	}
+	if scale == entity.HistogramLog && distribution.Min <= 0 {
+		validationErr := apperror.BadRequestError("logarithmic buckets require positive amounts")
+		validationErr.WithParams(map[string]string{"scale": "filter out amounts below or equal to zero, e.g. money_sum=gt:0"})
+		return histogram, validationErr
+	}
+
+	edges := histogramEdges(distribution.Min, distribution.Max, buckets, scale)
+	histogram.Buckets, err = s.repository.CountByBuckets(ctx, filterOptions, edges)
+	if err != nil {
+		span.RecordError(err)
+		return histogram, fmt.Errorf("failed to count operations by buckets: %w", err)
+	}
+	return histogram, nil
+}
+
+// histogramEdges returns buckets+1 ascending edges from min to max, or a single bucket when all
+// amounts are equal.
+func histogramEdges(min, max float64, buckets int, scale string) []float64 {
+	if min == max {
+		return []float64{min, max}
+	}
+
+	edges := make([]float64, buckets+1)
+	for k := range edges {
+		fraction := float64(k) / float64(buckets)
+		if scale == entity.HistogramLog {
+			edges[k] = min * math.Pow(max/min, fraction)
+		} else {
+			edges[k] = min + (max-min)*fraction
+		}
+	}
+	edges[0], edges[buckets] = min, max
+	return edges
+}
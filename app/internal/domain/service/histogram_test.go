@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"stats-service/internal/apperror"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/api/filter"
+	"testing"
+)
+
+func TestHistogramEdges(t *testing.T) {
+	tests := []struct {
+		name    string
+		min     float64
+		max     float64
+		buckets int
+		scale   string
+		edges   []float64
+	}{
+		{name: "all equal", min: 5, max: 5, buckets: 10, scale: entity.HistogramLinear, edges: []float64{5, 5}},
+		{name: "all equal logarithmic", min: 5, max: 5, buckets: 10, scale: entity.HistogramLog, edges: []float64{5, 5}},
+		{name: "single bucket", min: 0, max: 10, buckets: 1, scale: entity.HistogramLinear, edges: []float64{0, 10}},
+		{name: "linear", min: 0, max: 100, buckets: 4, scale: entity.HistogramLinear, edges: []float64{0, 25, 50, 75, 100}},
+		{name: "linear around zero", min: -10, max: 10, buckets: 2, scale: entity.HistogramLinear, edges: []float64{-10, 0, 10}},
+		{name: "logarithmic", min: 1, max: 1000, buckets: 3, scale: entity.HistogramLog, edges: []float64{1, 10, 100, 1000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			edges := histogramEdges(tt.min, tt.max, tt.buckets, tt.scale)
+			if len(edges) != len(tt.edges) {
+				t.Fatalf("histogramEdges() = %v, want %v", edges, tt.edges)
+			}
+			for i := range edges {
+				if math.Abs(edges[i]-tt.edges[i]) > 1e-9 {
+					t.Fatalf("histogramEdges() = %v, want %v", edges, tt.edges)
+				}
+			}
+			// the outer edges are exact so the smallest and the largest amount always fall into a bucket
+			if edges[0] != tt.min || edges[len(edges)-1] != tt.max {
+				t.Errorf("outer edges = %v, %v, want %v, %v", edges[0], edges[len(edges)-1], tt.min, tt.max)
+			}
+		})
+	}
+}
+
+// histogramRepository serves the distribution and records the edges the buckets are counted by.
+type histogramRepository struct {
+	Repository
+	distribution entity.Distribution
+	edges        []float64
+}
+
+func (r *histogramRepository) FindDistribution(context.Context, filter.Options) (entity.Distribution, error) {
+	return r.distribution, nil
+}
+
+func (r *histogramRepository) CountByBuckets(_ context.Context, _ filter.Options, edges []float64) ([]entity.HistogramBucket, error) {
+	r.edges = edges
+	return []entity.HistogramBucket{{From: edges[0], To: edges[len(edges)-1], Count: r.distribution.Count}}, nil
+}
+
+func TestGetHistogram(t *testing.T) {
+	tests := []struct {
+		name         string
+		distribution entity.Distribution
+		scale        string
+		edges        []float64
+		badRequest   bool
+	}{
+		{name: "no operations", scale: entity.HistogramLinear},
+		{name: "single operation", distribution: entity.Distribution{Count: 1, Min: 7, Max: 7}, scale: entity.HistogramLinear,
+			edges: []float64{7, 7}},
+		{name: "logarithmic with non-positive amounts", distribution: entity.Distribution{Count: 2, Min: 0, Max: 7},
+			scale: entity.HistogramLog, badRequest: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repository := &histogramRepository{distribution: tt.distribution}
+			histogram, err := (&service{repository: repository}).GetHistogram(context.Background(), nil, 10, tt.scale)
+
+			var appErr *apperror.AppError
+			if tt.badRequest != errors.As(err, &appErr) || (!tt.badRequest && err != nil) {
+				t.Fatalf("GetHistogram() error = %v, want bad request %v", err, tt.badRequest)
+			}
+			if len(repository.edges) != len(tt.edges) {
+				t.Errorf("buckets counted by edges %v, want %v", repository.edges, tt.edges)
+			}
+			if histogram.Buckets == nil {
+				t.Error("GetHistogram() buckets = nil, want a slice")
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"stats-service/internal/apperror"
+	"stats-service/internal/controller"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/tracing"
+	"strings"
+)
+
+type householdService struct {
+	households HouseholdRepository
+	logger     *logging.Logger
+}
+
+func NewHouseholdService(households HouseholdRepository, logger *logging.Logger) controller.HouseholdService {
+	return &householdService{
+		households: households,
+		logger:     logger,
+	}
+}
+
+// Create makes the caller the owner of the new household.
+func (s *householdService) Create(ctx context.Context, callerUUID string, dto entity.HouseholdDTO) (entity.Household, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.CreateHousehold")
+	defer span.Finish()
+
+	household := entity.Household{
+		Name:    strings.TrimSpace(dto.Name),
+		Members: []entity.HouseholdMember{{UserUUID: callerUUID, Role: entity.HouseholdRoleOwner, Accepted: true}},
+	}
+	if household.Name == "" {
+		validationErr := apperror.BadRequestError("household validation failed")
+		validationErr.WithFields(apperror.ErrorFields{"name": "is required"})
+		span.RecordError(validationErr)
+		return household, validationErr
+	}
+
+	var err error
+	household.UUID, err = s.households.Create(ctx, household)
+	if err != nil {
+		span.RecordError(err)
+		return household, fmt.Errorf("failed to create household: %w", err)
+	}
+	return household, nil
+}
+
+func (s *householdService) GetAll(ctx context.Context, callerUUID string) ([]entity.Household, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetHouseholds")
+	defer span.Finish()
+
+	households, err := s.households.FindAll(ctx, callerUUID)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("failed to get households: %w", err)
+	}
+	return households, nil
+}
+
+func (s *householdService) GetOne(ctx context.Context, callerUUID, uuid string) (entity.Household, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetHousehold")
+	defer span.Finish()
+
+	household, _, err := s.authorize(ctx, callerUUID, uuid, entity.HouseholdRoleMember)
+	if err != nil {
+		span.RecordError(err)
+		return household, err
+	}
+	return household, nil
+}
+
+func (s *householdService) Delete(ctx context.Context, callerUUID, uuid string) error {
+	ctx, span := tracing.StartSpan(ctx, "service.DeleteHousehold")
+	defer span.Finish()
+
+	if _, _, err := s.authorize(ctx, callerUUID, uuid, entity.HouseholdRoleOwner); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	if err := s.households.Delete(ctx, uuid); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to delete household: %w", err)
+	}
+	return nil
+}
+
+// SaveMember invites a user to the household or changes their role. Only owners manage members;
+// an invited user saving themselves accepts the invitation.
+func (s *householdService) SaveMember(ctx context.Context, callerUUID, uuid, userUUID string, dto entity.HouseholdMemberDTO) (entity.Household, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.SaveHouseholdMember")
+	defer span.Finish()
+
+	household, _, err := s.authorize(ctx, callerUUID, uuid, entity.HouseholdRoleOwner)
+	if errors.Is(err, apperror.ErrForbidden) && callerUUID == userUUID {
+		household, err = s.accept(ctx, callerUUID, uuid, dto)
+		if err != nil {
+			span.RecordError(err)
+		}
+		return household, err
+	}
+	if err != nil {
+		span.RecordError(err)
+		return household, err
+	}
+
+	member := entity.HouseholdMember{UserUUID: userUUID, Role: dto.Role}
+	if member.Role == "" {
+		member.Role = entity.HouseholdRoleMember
+	}
+	if member.Role != entity.HouseholdRoleOwner && member.Role != entity.HouseholdRoleMember {
+		validationErr := apperror.BadRequestError("household member validation failed")
+		validationErr.WithFields(apperror.ErrorFields{
+			"role": fmt.Sprintf("must be one of %s, %s", entity.HouseholdRoleOwner, entity.HouseholdRoleMember),
+		})
+		span.RecordError(validationErr)
+		return household, validationErr
+	}
+	if member.Role != entity.HouseholdRoleOwner {
+		if err = checkOwnerRemains(household, userUUID); err != nil {
+			span.RecordError(err)
+			return household, err
+		}
+	}
+
+	if err = s.households.SaveMember(ctx, uuid, member); err != nil {
+		span.RecordError(err)
+		return household, fmt.Errorf("failed to save household member: %w", err)
+	}
+
+	household, err = s.households.FindOne(ctx, uuid)
+	if err != nil {
+		span.RecordError(err)
+		return household, fmt.Errorf("failed to get household: %w", err)
+	}
+	return household, nil
+}
+
+// accept accepts the pending invitation of the caller, whose role was chosen by the inviting owner.
+func (s *householdService) accept(ctx context.Context, callerUUID, uuid string, dto entity.HouseholdMemberDTO) (entity.Household, error) {
+	household, err := s.households.FindOne(ctx, uuid)
+	if err != nil {
+		return household, fmt.Errorf("failed to get household: %w", err)
+	}
+	invitation, ok := household.Invitation(callerUUID)
+	if !ok || invitation.Accepted {
+		return entity.Household{}, apperror.ErrForbidden
+	}
+	if dto.Role != "" && dto.Role != invitation.Role {
+		validationErr := apperror.BadRequestError("household member validation failed")
+		validationErr.WithFields(apperror.ErrorFields{"role": "is chosen by the owner sending the invitation"})
+		return household, validationErr
+	}
+
+	if err = s.households.AcceptMember(ctx, uuid, callerUUID); err != nil {
+		return household, fmt.Errorf("failed to accept household invitation: %w", err)
+	}
+	household, err = s.households.FindOne(ctx, uuid)
+	if err != nil {
+		return household, fmt.Errorf("failed to get household: %w", err)
+	}
+	return household, nil
+}
+
+// DeleteMember removes a user from the household. Owners remove anybody, members leave and
+// invited users decline by removing themselves.
+func (s *householdService) DeleteMember(ctx context.Context, callerUUID, uuid, userUUID string) error {
+	ctx, span := tracing.StartSpan(ctx, "service.DeleteHouseholdMember")
+	defer span.Finish()
+
+	household, err := s.households.FindOne(ctx, uuid)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to get household: %w", err)
+	}
+	if callerUUID == userUUID {
+		if _, ok := household.Invitation(userUUID); !ok {
+			return apperror.ErrForbidden
+		}
+	} else if caller, ok := household.Member(callerUUID); !ok || caller.Role != entity.HouseholdRoleOwner {
+		return apperror.ErrForbidden
+	} else if _, ok = household.Invitation(userUUID); !ok {
+		return apperror.ErrNotFound
+	}
+	if err = checkOwnerRemains(household, userUUID); err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if err = s.households.DeleteMember(ctx, uuid, userUUID); err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to delete household member: %w", err)
+	}
+	return nil
+}
+
+// authorize loads the household and checks that the caller is a member who accepted the invitation
+// with at least role.
+func (s *householdService) authorize(ctx context.Context, callerUUID, uuid string, role entity.HouseholdRole) (entity.Household, entity.HouseholdMember, error) {
+	household, err := s.households.FindOne(ctx, uuid)
+	if err != nil {
+		return household, entity.HouseholdMember{}, fmt.Errorf("failed to get household: %w", err)
+	}
+
+	caller, ok := household.Member(callerUUID)
+	if !ok || (role == entity.HouseholdRoleOwner && caller.Role != entity.HouseholdRoleOwner) {
+		return entity.Household{}, caller, apperror.ErrForbidden
+	}
+	return household, caller, nil
+}
+
+// checkOwnerRemains fails when userUUID is the last owner of the household, which would leave
+// nobody able to manage it.
+func checkOwnerRemains(household entity.Household, userUUID string) error {
+	for _, member := range household.Members {
+		if member.Role == entity.HouseholdRoleOwner && member.Accepted && member.UserUUID != userUUID {
+			return nil
+		}
+	}
+	if member, ok := household.Member(userUUID); !ok || member.Role != entity.HouseholdRoleOwner {
+		return nil
+	}
+	return apperror.BadRequestError("a household needs at least one owner, make another member owner first")
+}
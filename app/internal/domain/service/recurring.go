@@ -0,0 +1,172 @@
+package service
+
+import (
+	"math"
+	"sort"
+	"stats-service/internal/domain/analytics"
+	"stats-service/internal/domain/entity"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	recurringMinOccurrences = 3
+	recurringMinConfidence  = 0.5
+	// amounts within this relative distance from the median amount of a group count as the same charge
+	recurringAmountTolerance = 0.2
+)
+
+type recurringInterval struct {
+	period    entity.RecurringPeriod
+	minDays   float64
+	maxDays   float64
+	tolerance float64
+	days      float64
+}
+
+var recurringIntervals = []recurringInterval{
+	{period: entity.RecurringWeekly, minDays: 6, maxDays: 8, tolerance: 2, days: 7},
+	{period: entity.RecurringMonthly, minDays: 27, maxDays: 33, tolerance: 4, days: 30.44},
+	{period: entity.RecurringYearly, minDays: 355, maxDays: 375, tolerance: 10, days: 365.25},
+}
+
+// normalizeDescription reduces a description to the words identifying the counterparty,
+// dropping case, digits (dates, card and invoice numbers) and punctuation.
+func normalizeDescription(description string) string {
+	var builder strings.Builder
+	for _, r := range strings.ToLower(description) {
+		if unicode.IsLetter(r) {
+			builder.WriteRune(r)
+		} else {
+			builder.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// recurringMatch is a detected series with the operations that belong to it.
+type recurringMatch struct {
+	series     entity.RecurringSeries
+	operations []entity.Operation
+}
+
+// detectRecurring groups operations by category and normalized description and returns the groups
+// that repeat weekly, monthly or yearly with a similar amount, most confident first.
+// operations must be sorted by date.
+func detectRecurring(operations []entity.Operation, now time.Time) []entity.RecurringSeries {
+	matches := findRecurring(operations, now)
+	series := make([]entity.RecurringSeries, 0, len(matches))
+	for _, match := range matches {
+		series = append(series, match.series)
+	}
+	return series
+}
+
+func findRecurring(operations []entity.Operation, now time.Time) []recurringMatch {
+	type groupKey struct {
+		category    string
+		description string
+	}
+	groups := make(map[groupKey][]entity.Operation)
+	keys := make([]groupKey, 0)
+	for _, op := range operations {
+		key := groupKey{category: op.CategoryUUID, description: normalizeDescription(op.Description)}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], op)
+	}
+
+	matches := make([]recurringMatch, 0)
+	for _, key := range keys {
+		if match, ok := detectSeries(groups[key], now); ok {
+			matches = append(matches, match)
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].series.Confidence > matches[j].series.Confidence
+	})
+	return matches
+}
+
+func detectSeries(group []entity.Operation, now time.Time) (recurringMatch, bool) {
+	var match recurringMatch
+	if len(group) < recurringMinOccurrences {
+		return match, false
+	}
+
+	amounts := make([]float64, 0, len(group))
+	for _, op := range group {
+		amounts = append(amounts, math.Abs(op.MoneySum))
+	}
+	typicalAmount := analytics.Median(amounts)
+	if typicalAmount == 0 {
+		return match, false
+	}
+
+	similar := make([]entity.Operation, 0, len(group))
+	similarAmounts := make([]float64, 0, len(group))
+	for i, op := range group {
+		if math.Abs(amounts[i]-typicalAmount)/typicalAmount <= recurringAmountTolerance {
+			similar = append(similar, op)
+			similarAmounts = append(similarAmounts, amounts[i])
+		}
+	}
+	if len(similar) < recurringMinOccurrences {
+		return match, false
+	}
+
+	gaps := make([]float64, 0, len(similar)-1)
+	for i := 1; i < len(similar); i++ {
+		gaps = append(gaps, similar[i].DateTime.Sub(similar[i-1].DateTime).Hours()/24)
+	}
+	medianGap := analytics.Median(gaps)
+
+	var interval *recurringInterval
+	for i := range recurringIntervals {
+		if medianGap >= recurringIntervals[i].minDays && medianGap <= recurringIntervals[i].maxDays {
+			interval = &recurringIntervals[i]
+			break
+		}
+	}
+	if interval == nil {
+		return match, false
+	}
+
+	regular := 0
+	for _, gap := range gaps {
+		if math.Abs(gap-interval.days) <= interval.tolerance {
+			regular++
+		}
+	}
+
+	// the share of operations with the typical amount, the share of regular gaps and the number
+	// of occurrences all raise the confidence
+	amountScore := float64(len(similar)) / float64(len(group))
+	regularityScore := float64(regular) / float64(len(gaps))
+	countScore := 1 - 1/float64(len(similar))
+	confidence := math.Round(amountScore*regularityScore*countScore*100) / 100
+	if confidence < recurringMinConfidence {
+		return match, false
+	}
+
+	first := similar[0]
+	last := similar[len(similar)-1]
+	next := interval.period.NextAfter(last.DateTime)
+	match.operations = similar
+	match.series = entity.RecurringSeries{
+		CategoryUUID:     last.CategoryUUID,
+		Description:      last.Description,
+		Period:           interval.period,
+		Occurrences:      len(similar),
+		TypicalAmount:    analytics.Median(similarAmounts),
+		FirstDate:        first.DateTime,
+		LastDate:         last.DateTime,
+		NextExpectedDate: next,
+		Confidence:       confidence,
+		Active:           now.Sub(next).Hours()/24 <= interval.tolerance,
+	}
+	return match, true
+}
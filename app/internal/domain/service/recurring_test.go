@@ -0,0 +1,93 @@
+package service
+
+import (
+	"stats-service/internal/domain/entity"
+	"testing"
+	"time"
+)
+
+func TestDetectSeries(t *testing.T) {
+	start := time.Date(2024, time.January, 15, 10, 0, 0, 0, time.UTC)
+	operations := func(count int, step func(i int) time.Time, amount func(i int) float64) []entity.Operation {
+		group := make([]entity.Operation, 0, count)
+		for i := 0; i < count; i++ {
+			group = append(group, entity.Operation{
+				CategoryUUID: "category",
+				Description:  "Streaming",
+				MoneySum:     amount(i),
+				DateTime:     step(i),
+			})
+		}
+		return group
+	}
+	monthly := func(i int) time.Time { return start.AddDate(0, i, 0) }
+	weekly := func(i int) time.Time { return start.AddDate(0, 0, 7*i) }
+	equal := func(int) float64 { return 9.99 }
+
+	tests := []struct {
+		name       string
+		group      []entity.Operation
+		now        time.Time
+		ok         bool
+		period     entity.RecurringPeriod
+		occurrence int
+		confidence float64
+		active     bool
+	}{
+		{name: "empty", group: nil, now: start},
+		{name: "single occurrence", group: operations(1, monthly, equal), now: start},
+		{name: "below minimum occurrences", group: operations(2, monthly, equal), now: start},
+		{
+			name: "monthly with equal amounts", group: operations(6, monthly, equal), now: start.AddDate(0, 6, 0),
+			ok: true, period: entity.RecurringMonthly, occurrence: 6, confidence: 0.83, active: true,
+		},
+		{
+			name: "weekly with equal amounts", group: operations(4, weekly, equal), now: start.AddDate(0, 0, 28),
+			ok: true, period: entity.RecurringWeekly, occurrence: 4, confidence: 0.75, active: true,
+		},
+		{
+			name: "inactive after missed occurrences", group: operations(6, monthly, equal), now: start.AddDate(0, 9, 0),
+			ok: true, period: entity.RecurringMonthly, occurrence: 6, confidence: 0.83, active: false,
+		},
+		{
+			name: "outlying amount left out", now: start.AddDate(0, 6, 0),
+			group: operations(6, monthly, func(i int) float64 {
+				if i == 2 {
+					return 120
+				}
+				return 9.99
+			}),
+			ok: true, period: entity.RecurringMonthly, occurrence: 5, confidence: 0.5, active: true,
+		},
+		{name: "zero amounts", group: operations(6, monthly, func(int) float64 { return 0 }), now: start},
+		{name: "same day", group: operations(6, func(int) time.Time { return start }, equal), now: start},
+		{name: "irregular interval", group: operations(6, func(i int) time.Time { return start.AddDate(0, 0, 15*i) }, equal), now: start},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, ok := detectSeries(tt.group, tt.now)
+			if ok != tt.ok {
+				t.Fatalf("detectSeries() ok = %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			series := match.series
+			if series.Period != tt.period {
+				t.Errorf("period = %s, want %s", series.Period, tt.period)
+			}
+			if series.Occurrences != tt.occurrence || len(match.operations) != tt.occurrence {
+				t.Errorf("occurrences = %d (%d operations), want %d", series.Occurrences, len(match.operations), tt.occurrence)
+			}
+			if series.Confidence != tt.confidence {
+				t.Errorf("confidence = %v, want %v", series.Confidence, tt.confidence)
+			}
+			if series.Active != tt.active {
+				t.Errorf("active = %v, want %v", series.Active, tt.active)
+			}
+			if series.TypicalAmount != 9.99 {
+				t.Errorf("typical amount = %v, want 9.99", series.TypicalAmount)
+			}
+		})
+	}
+}
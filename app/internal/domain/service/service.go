@@ -9,6 +9,8 @@ import (
 	"stats-service/pkg/api/filter"
 	"stats-service/pkg/api/sort"
 	"stats-service/pkg/logging"
+	"stats-service/pkg/tracing"
+	"time"
 )
 
 type service struct {
@@ -24,17 +26,157 @@ func NewService(repository Repository, logger *logging.Logger) controller.Servic
 }
 
 func (s *service) GetAll(ctx context.Context, sortOptions sort.Options, filterOptions filter.Options) (entity.Report, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetAll")
+	defer span.Finish()
+
 	var report entity.Report
 	sortOpt, err := sorting.NewSortOptions(sortOptions.Field, sortOptions.Order)
 	if err != nil {
+		span.RecordError(err)
 		return report, err
 	}
 
 	operations, err := s.repository.FindAll(ctx, sortOpt, filterOptions)
 	if err != nil {
+		span.RecordError(err)
 		return report, fmt.Errorf("failed to get operations: %w", err)
 	}
 
-	report = entity.NewReport(operations)
+	distribution, err := s.repository.FindDistribution(ctx, filterOptions)
+	if err != nil {
+		span.RecordError(err)
+		return report, fmt.Errorf("failed to get operations distribution: %w", err)
+	}
+
+	report = entity.NewReport(operations, distribution)
+	if report.Members, err = s.members(ctx, filterOptions); err != nil {
+		span.RecordError(err)
+		return report, err
+	}
+	span.SetAttribute("report.operations", len(operations))
 	return report, nil
 }
+
+func (s *service) Explain(ctx context.Context, sortOptions sort.Options, filterOptions filter.Options) (entity.QueryPlan, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.Explain")
+	defer span.Finish()
+
+	var queryPlan entity.QueryPlan
+	sortOpt, err := sorting.NewSortOptions(sortOptions.Field, sortOptions.Order)
+	if err != nil {
+		span.RecordError(err)
+		return queryPlan, err
+	}
+
+	queryPlan, err = s.repository.ExplainFindAll(ctx, sortOpt, filterOptions)
+	if err != nil {
+		span.RecordError(err)
+		return queryPlan, fmt.Errorf("failed to explain operations query: %w", err)
+	}
+	return queryPlan, nil
+}
+
+func (s *service) Snapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	return s.repository.Snapshot(ctx, fn)
+}
+
+func (s *service) GetVersion(ctx context.Context, filterOptions filter.Options) (entity.ReportVersion, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetVersion")
+	defer span.Finish()
+
+	version, err := s.repository.FindVersion(ctx, filterOptions)
+	if err != nil {
+		span.RecordError(err)
+		return version, fmt.Errorf("failed to get operations version: %w", err)
+	}
+	return version, nil
+}
+
+func (s *service) GetRecurring(ctx context.Context, filterOptions filter.Options) (entity.RecurringReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetRecurring")
+	defer span.Finish()
+
+	var report entity.RecurringReport
+	operations, err := s.findByDate(ctx, filterOptions)
+	if err != nil {
+		span.RecordError(err)
+		return report, err
+	}
+
+	report.Series = detectRecurring(operations, time.Now())
+	span.SetAttribute("report.operations", len(operations))
+	span.SetAttribute("report.series", len(report.Series))
+	return report, nil
+}
+
+// findByDate returns the operations matching filterOptions in chronological order.
+func (s *service) findByDate(ctx context.Context, filterOptions filter.Options) ([]entity.Operation, error) {
+	sortOpt, err := sorting.NewSortOptions(entity.DateTime, sort.ASC)
+	if err != nil {
+		return nil, err
+	}
+
+	operations, err := s.repository.FindAll(ctx, sortOpt, filterOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operations: %w", err)
+	}
+	return operations, nil
+}
+
+func (s *service) GetHeatmap(ctx context.Context, filterOptions filter.Options, timezone string) (entity.Heatmap, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetHeatmap")
+	defer span.Finish()
+	span.SetAttribute("heatmap.timezone", timezone)
+
+	heatmap, err := s.repository.FindHeatmap(ctx, filterOptions, timezone)
+	if err != nil {
+		span.RecordError(err)
+		return heatmap, fmt.Errorf("failed to get operations heatmap: %w", err)
+	}
+	if heatmap.Members, err = s.members(ctx, filterOptions); err != nil {
+		span.RecordError(err)
+		return heatmap, err
+	}
+	return heatmap, nil
+}
+
+// members breaks the operations matching filterOptions down to users, including the users without
+// any operations. It returns nil unless filterOptions covers several users.
+func (s *service) members(ctx context.Context, filterOptions filter.Options) ([]entity.MemberTotal, error) {
+	users := filterUsers(filterOptions)
+	if len(users) < 2 {
+		return nil, nil
+	}
+
+	totals, err := s.repository.SumByUser(ctx, filterOptions)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get operations by user: %w", err)
+	}
+
+	byUser := make(map[string]entity.MemberTotal, len(totals))
+	for _, total := range totals {
+		byUser[total.UserUUID] = total
+	}
+	members := make([]entity.MemberTotal, 0, len(users))
+	for _, user := range users {
+		total, ok := byUser[user]
+		if !ok {
+			total.UserUUID = user
+		}
+		members = append(members, total)
+	}
+	return members, nil
+}
+
+// filterUsers returns the users filterOptions is narrowed to.
+func filterUsers(filterOptions filter.Options) []string {
+	if filterOptions == nil {
+		return nil
+	}
+	for _, field := range filterOptions.Fields() {
+		if field.Name == entity.UserUUID {
+			return field.Values
+		}
+	}
+	return nil
+}
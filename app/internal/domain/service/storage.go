@@ -5,8 +5,59 @@ import (
 	"stats-service/internal/domain/entity"
 	"stats-service/internal/storage/sorting"
 	"stats-service/pkg/api/filter"
+	"time"
 )
 
 type Repository interface {
+	// Snapshot runs fn so that every read made with the context passed to it sees the same data.
+	Snapshot(ctx context.Context, fn func(ctx context.Context) error) error
 	FindAll(ctx context.Context, sortOptions sorting.SortOptions, filterOptions filter.Options) ([]entity.Operation, error)
+	ExplainFindAll(ctx context.Context, sortOptions sorting.SortOptions, filterOptions filter.Options) (entity.QueryPlan, error)
+	FindVersion(ctx context.Context, filterOptions filter.Options) (entity.ReportVersion, error)
+	FindCategory(ctx context.Context, uuid string) (entity.Category, error)
+	SumOperations(ctx context.Context, scope entity.SpendingScope, from, to time.Time) (float64, error)
+	FindAllWithType(ctx context.Context, filterOptions filter.Options) ([]entity.TypedOperation, error)
+	SumByCategory(ctx context.Context, filterOptions filter.Options, from, to time.Time) ([]entity.CategoryTotal, error)
+	FindBalance(ctx context.Context, filterOptions filter.Options, interval string) ([]entity.BalancePoint, error)
+	FindDistribution(ctx context.Context, filterOptions filter.Options) (entity.Distribution, error)
+	CountByBuckets(ctx context.Context, filterOptions filter.Options, edges []float64) ([]entity.HistogramBucket, error)
+	FindTop(ctx context.Context, filterOptions filter.Options, by, metric string) ([]entity.TopItem, float64, int64, error)
+	FindHeatmap(ctx context.Context, filterOptions filter.Options, timezone string) (entity.Heatmap, error)
+	SumByUser(ctx context.Context, filterOptions filter.Options) ([]entity.MemberTotal, error)
+}
+
+type BudgetRepository interface {
+	Create(ctx context.Context, budget entity.Budget) (string, error)
+	FindAll(ctx context.Context, userUUID string) ([]entity.Budget, error)
+	FindOne(ctx context.Context, uuid string) (entity.Budget, error)
+	Update(ctx context.Context, budget entity.Budget) error
+	Delete(ctx context.Context, uuid string) error
+}
+
+type GoalRepository interface {
+	Create(ctx context.Context, goal entity.Goal) (string, error)
+	FindAll(ctx context.Context, userUUID string) ([]entity.Goal, error)
+	FindOne(ctx context.Context, uuid string) (entity.Goal, error)
+	Update(ctx context.Context, goal entity.Goal) error
+	Delete(ctx context.Context, uuid string) error
+}
+
+type HouseholdRepository interface {
+	Create(ctx context.Context, household entity.Household) (string, error)
+	FindAll(ctx context.Context, userUUID string) ([]entity.Household, error)
+	FindOne(ctx context.Context, uuid string) (entity.Household, error)
+	Delete(ctx context.Context, uuid string) error
+	SaveMember(ctx context.Context, householdUUID string, member entity.HouseholdMember) error
+	AcceptMember(ctx context.Context, householdUUID, userUUID string) error
+	DeleteMember(ctx context.Context, householdUUID, userUUID string) error
+}
+
+type AlertRepository interface {
+	Create(ctx context.Context, rule entity.AlertRule) (string, error)
+	FindAll(ctx context.Context, userUUID string) ([]entity.AlertRule, error)
+	FindOne(ctx context.Context, uuid string) (entity.AlertRule, error)
+	FindEnabled(ctx context.Context) ([]entity.AlertRule, error)
+	Update(ctx context.Context, rule entity.AlertRule) error
+	Delete(ctx context.Context, uuid string) error
+	SaveState(ctx context.Context, state entity.AlertState) error
 }
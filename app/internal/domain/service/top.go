@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/api/filter"
+	"stats-service/pkg/tracing"
+)
+
+const topOtherKey = "other"
+
+// GetTop ranks categories, normalized descriptions or single operations by total or count and
+// aggregates everything outside the first filterOptions.Limit() items into the other bucket.
+func (s *service) GetTop(ctx context.Context, filterOptions filter.Options, by, metric string) (entity.TopReport, error) {
+	ctx, span := tracing.StartSpan(ctx, "service.GetTop")
+	defer span.Finish()
+	span.SetAttribute("top.by", by)
+
+	report := entity.TopReport{By: by, Metric: metric, Items: make([]entity.TopItem, 0)}
+	items, total, count, err := s.repository.FindTop(ctx, filterOptions, by, metric)
+	if err != nil {
+		span.RecordError(err)
+		return report, fmt.Errorf("failed to get top %ss: %w", by, err)
+	}
+
+	report.Total = roundMoney(total)
+	report.Count = count
+	report.Other = entity.TopItem{Key: topOtherKey, Label: topOtherKey, Total: total, Count: count}
+	for _, item := range items {
+		report.Other.Total -= item.Total
+		report.Other.Count -= item.Count
+		item.Percent = topPercent(item, metric, total, count)
+		report.Items = append(report.Items, item)
+	}
+	report.Other.Total = roundMoney(report.Other.Total)
+	report.Other.Percent = topPercent(report.Other, metric, total, count)
+	if report.Members, err = s.members(ctx, filterOptions); err != nil {
+		span.RecordError(err)
+		return report, err
+	}
+
+	span.SetAttribute("report.items", len(report.Items))
+	return report, nil
+}
+
+func topPercent(item entity.TopItem, metric string, total float64, count int64) float64 {
+	if metric == entity.TopMetricCount {
+		if count == 0 {
+			return 0
+		}
+		return math.Round(float64(item.Count)/float64(count)*10000) / 100
+	}
+	if total == 0 {
+		return 0
+	}
+	return math.Round(item.Total/total*10000) / 100
+}
@@ -0,0 +1,274 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"stats-service/internal/apperror"
+	"stats-service/internal/domain/entity"
+	"stats-service/internal/domain/service"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/postgresql"
+	"stats-service/pkg/utils"
+	"time"
+)
+
+type alertRepository struct {
+	client postgresql.Client
+	logger *logging.Logger
+}
+
+func NewAlertRepository(client postgresql.Client, logger *logging.Logger) service.AlertRepository {
+	return &alertRepository{
+		client: client,
+		logger: logger,
+	}
+}
+
+func selectAlertRules() squirrel.SelectBuilder {
+	return squirrel.Select("r.id, r.user_id, r.kind, coalesce(r.category_id::text, ''), coalesce(r.category_type, '')",
+		"coalesce(r.budget_id::text, ''), coalesce(r.period, ''), r.threshold::float8, r.webhook_url, r.enabled",
+		"s.period_start, s.firing, s.last_value, s.triggered_at, s.evaluated_at").
+		From("stats.alert_rules r").
+		LeftJoin("stats.alert_states s ON s.rule_id = r.id").
+		PlaceholderFormat(squirrel.Dollar)
+}
+
+func scanAlertRule(row pgx.Row) (entity.AlertRule, error) {
+	var rule entity.AlertRule
+	var kind, categoryType, period string
+	var state entity.AlertState
+	var firing *bool
+	var lastValue *float64
+	var periodStart, evaluatedAt *time.Time
+
+	err := row.Scan(&rule.UUID, &rule.UserUUID, &kind, &rule.CategoryUUID, &categoryType,
+		&rule.BudgetUUID, &period, &rule.Threshold, &rule.WebhookURL, &rule.Enabled,
+		&periodStart, &firing, &lastValue, &state.TriggeredAt, &evaluatedAt)
+	if err != nil {
+		return rule, err
+	}
+
+	rule.Kind = entity.AlertKind(kind)
+	rule.CategoryType = entity.CategoryType(categoryType)
+	rule.Period = entity.BudgetPeriod(period)
+	if periodStart != nil {
+		state.RuleUUID = rule.UUID
+		state.PeriodStart = *periodStart
+		state.Firing = *firing
+		state.LastValue = *lastValue
+		state.EvaluatedAt = *evaluatedAt
+		rule.State = &state
+	}
+	return rule, nil
+}
+
+func (r *alertRepository) Create(ctx context.Context, rule entity.AlertRule) (string, error) {
+	sql, i, err := squirrel.Insert("stats.alert_rules").
+		Columns("user_id", "kind", "category_id", "category_type", "budget_id", "period", "threshold",
+			"webhook_url", "enabled").
+		Values(rule.UserUUID, string(rule.Kind), nullableString(rule.CategoryUUID),
+			nullableString(string(rule.CategoryType)), nullableString(rule.BudgetUUID),
+			nullableString(string(rule.Period)), rule.Threshold, rule.WebhookURL, rule.Enabled).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.CreateAlertRule", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	var uuid string
+	if err = r.client.QueryRow(nCtx, sql, i...).Scan(&uuid); err != nil {
+		span.RecordError(err)
+		return "", handleSQLError(err, r.logger)
+	}
+	return uuid, nil
+}
+
+func (r *alertRepository) FindAll(ctx context.Context, userUUID string) ([]entity.AlertRule, error) {
+	return r.findMany(ctx, "repository.FindAlertRules",
+		selectAlertRules().Where(squirrel.Eq{"r.user_id": userUUID}).OrderBy("r.created_at"))
+}
+
+func (r *alertRepository) FindEnabled(ctx context.Context) ([]entity.AlertRule, error) {
+	return r.findMany(ctx, "repository.FindEnabledAlertRules",
+		selectAlertRules().Where(squirrel.Eq{"r.enabled": true}).OrderBy("r.created_at"))
+}
+
+func (r *alertRepository) findMany(ctx context.Context, spanName string, qb squirrel.SelectBuilder) ([]entity.AlertRule, error) {
+	sql, i, err := qb.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, spanName, sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	rules := make([]entity.AlertRule, 0)
+	for rows.Next() {
+		rule, err := scanAlertRule(rows)
+		if err != nil {
+			span.RecordError(err)
+			return nil, handleSQLError(err, r.logger)
+		}
+		rules = append(rules, rule)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	span.SetAttribute("db.rows", len(rules))
+
+	return rules, nil
+}
+
+func (r *alertRepository) FindOne(ctx context.Context, uuid string) (entity.AlertRule, error) {
+	sql, i, err := selectAlertRules().Where(squirrel.Eq{"r.id": uuid}).ToSql()
+	if err != nil {
+		return entity.AlertRule{}, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindAlertRule", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	rule, err := scanAlertRule(r.client.QueryRow(nCtx, sql, i...))
+	if err != nil {
+		span.RecordError(err)
+		return rule, handleSQLError(err, r.logger)
+	}
+	return rule, nil
+}
+
+// Update replaces the rule definition and forgets its state, so the changed rule is evaluated afresh.
+func (r *alertRepository) Update(ctx context.Context, rule entity.AlertRule) error {
+	sql, i, err := squirrel.Update("stats.alert_rules").
+		Set("user_id", rule.UserUUID).
+		Set("kind", string(rule.Kind)).
+		Set("category_id", nullableString(rule.CategoryUUID)).
+		Set("category_type", nullableString(string(rule.CategoryType))).
+		Set("budget_id", nullableString(rule.BudgetUUID)).
+		Set("period", nullableString(string(rule.Period))).
+		Set("threshold", rule.Threshold).
+		Set("webhook_url", rule.WebhookURL).
+		Set("enabled", rule.Enabled).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": rule.UUID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.UpdateAlertRule", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		span.RecordError(err)
+		return handleSQLError(err, r.logger)
+	}
+	defer func() {
+		_ = tx.Rollback(nCtx)
+	}()
+
+	tag, err := tx.Exec(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return handleSQLError(err, r.logger)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	if _, err = tx.Exec(nCtx, "DELETE FROM stats.alert_states WHERE rule_id = $1", rule.UUID); err != nil {
+		span.RecordError(err)
+		return handleSQLError(err, r.logger)
+	}
+
+	if err = tx.Commit(nCtx); err != nil {
+		span.RecordError(err)
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+func (r *alertRepository) Delete(ctx context.Context, uuid string) error {
+	sql, i, err := squirrel.Delete("stats.alert_rules").
+		Where(squirrel.Eq{"id": uuid}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.DeleteAlertRule", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tag, err := r.client.Exec(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return handleSQLError(err, r.logger)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
+
+func (r *alertRepository) SaveState(ctx context.Context, state entity.AlertState) error {
+	sql, i, err := squirrel.Insert("stats.alert_states").
+		Columns("rule_id", "period_start", "firing", "last_value", "triggered_at", "evaluated_at").
+		Values(state.RuleUUID, state.PeriodStart, state.Firing, state.LastValue, state.TriggeredAt,
+			state.EvaluatedAt).
+		Suffix("ON CONFLICT (rule_id) DO UPDATE SET period_start = excluded.period_start, " +
+			"firing = excluded.firing, last_value = excluded.last_value, " +
+			"triggered_at = excluded.triggered_at, evaluated_at = excluded.evaluated_at").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.SaveAlertState", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	if _, err = r.client.Exec(nCtx, sql, i...); err != nil {
+		span.RecordError(err)
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"stats-service/internal/apperror"
+	"stats-service/internal/domain/entity"
+	"stats-service/internal/domain/service"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/postgresql"
+	"stats-service/pkg/utils"
+)
+
+const budgetColumns = "id, user_id, coalesce(category_id::text, ''), coalesce(category_type, ''), amount::float8, " +
+	"period, start_date, end_date"
+
+type budgetRepository struct {
+	client postgresql.Client
+	logger *logging.Logger
+}
+
+// NewBudgetRepository creates a repository for budgets. Budgets are edited by users and read
+// right after, so unlike operations they are always queried on the primary.
+func NewBudgetRepository(client postgresql.Client, logger *logging.Logger) service.BudgetRepository {
+	return &budgetRepository{
+		client: client,
+		logger: logger,
+	}
+}
+
+func nullableString(value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	return value
+}
+
+func scanBudget(row pgx.Row) (entity.Budget, error) {
+	var budget entity.Budget
+	var categoryType, period string
+	err := row.Scan(&budget.UUID, &budget.UserUUID, &budget.CategoryUUID, &categoryType, &budget.Amount,
+		&period, &budget.StartDate, &budget.EndDate)
+	budget.CategoryType = entity.CategoryType(categoryType)
+	budget.Period = entity.BudgetPeriod(period)
+	return budget, err
+}
+
+func (r *budgetRepository) Create(ctx context.Context, budget entity.Budget) (string, error) {
+	sql, i, err := squirrel.Insert("stats.budgets").
+		Columns("user_id", "category_id", "category_type", "amount", "period", "start_date", "end_date").
+		Values(budget.UserUUID, nullableString(budget.CategoryUUID), nullableString(string(budget.CategoryType)),
+			budget.Amount, string(budget.Period), budget.StartDate, budget.EndDate).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.CreateBudget", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	var uuid string
+	if err = r.client.QueryRow(nCtx, sql, i...).Scan(&uuid); err != nil {
+		span.RecordError(err)
+		return "", handleSQLError(err, r.logger)
+	}
+	return uuid, nil
+}
+
+func (r *budgetRepository) FindAll(ctx context.Context, userUUID string) ([]entity.Budget, error) {
+	sql, i, err := squirrel.Select(budgetColumns).
+		From("stats.budgets").
+		Where(squirrel.Eq{"user_id": userUUID}).
+		OrderBy("created_at").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindBudgets", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	budgets := make([]entity.Budget, 0)
+	for rows.Next() {
+		budget, err := scanBudget(rows)
+		if err != nil {
+			span.RecordError(err)
+			return nil, handleSQLError(err, r.logger)
+		}
+		budgets = append(budgets, budget)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	span.SetAttribute("db.rows", len(budgets))
+
+	return budgets, nil
+}
+
+func (r *budgetRepository) FindOne(ctx context.Context, uuid string) (entity.Budget, error) {
+	sql, i, err := squirrel.Select(budgetColumns).
+		From("stats.budgets").
+		Where(squirrel.Eq{"id": uuid}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return entity.Budget{}, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindBudget", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	budget, err := scanBudget(r.client.QueryRow(nCtx, sql, i...))
+	if err != nil {
+		span.RecordError(err)
+		return budget, handleSQLError(err, r.logger)
+	}
+	return budget, nil
+}
+
+func (r *budgetRepository) Update(ctx context.Context, budget entity.Budget) error {
+	sql, i, err := squirrel.Update("stats.budgets").
+		Set("user_id", budget.UserUUID).
+		Set("category_id", nullableString(budget.CategoryUUID)).
+		Set("category_type", nullableString(string(budget.CategoryType))).
+		Set("amount", budget.Amount).
+		Set("period", string(budget.Period)).
+		Set("start_date", budget.StartDate).
+		Set("end_date", budget.EndDate).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": budget.UUID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+
+	return execAffectingOne(ctx, r.client, r.logger, "repository.UpdateBudget", sql, i)
+}
+
+func (r *budgetRepository) Delete(ctx context.Context, uuid string) error {
+	sql, i, err := squirrel.Delete("stats.budgets").
+		Where(squirrel.Eq{"id": uuid}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+
+	return execAffectingOne(ctx, r.client, r.logger, "repository.DeleteBudget", sql, i)
+}
+
+// execAffectingOne runs a statement changing a single row by id and reports apperror.ErrNotFound
+// when there is no such row.
+func execAffectingOne(ctx context.Context, client postgresql.Client, logger *logging.Logger, spanName, sql string, args []interface{}) error {
+	logging.LoggerFromContext(ctx, logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, spanName, sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tag, err := client.Exec(nCtx, sql, args...)
+	if err != nil {
+		span.RecordError(err)
+		return handleSQLError(err, logger)
+	}
+	if tag.RowsAffected() == 0 {
+		return apperror.ErrNotFound
+	}
+	return nil
+}
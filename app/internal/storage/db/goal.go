@@ -0,0 +1,165 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"stats-service/internal/domain/entity"
+	"stats-service/internal/domain/service"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/postgresql"
+	"stats-service/pkg/utils"
+)
+
+const goalColumns = "id, user_id, name, target_amount::float8, rule, coalesce(category_id::text, ''), " +
+	"start_date, deadline"
+
+type goalRepository struct {
+	client postgresql.Client
+	logger *logging.Logger
+}
+
+// NewGoalRepository creates a repository for goals, queried on the primary like budgets.
+func NewGoalRepository(client postgresql.Client, logger *logging.Logger) service.GoalRepository {
+	return &goalRepository{
+		client: client,
+		logger: logger,
+	}
+}
+
+func scanGoal(row pgx.Row) (entity.Goal, error) {
+	var goal entity.Goal
+	var rule string
+	err := row.Scan(&goal.UUID, &goal.UserUUID, &goal.Name, &goal.TargetAmount, &rule, &goal.CategoryUUID,
+		&goal.StartDate, &goal.Deadline)
+	goal.Rule = entity.GoalRule(rule)
+	return goal, err
+}
+
+func (r *goalRepository) Create(ctx context.Context, goal entity.Goal) (string, error) {
+	sql, i, err := squirrel.Insert("stats.goals").
+		Columns("user_id", "name", "target_amount", "rule", "category_id", "start_date", "deadline").
+		Values(goal.UserUUID, goal.Name, goal.TargetAmount, string(goal.Rule), nullableString(goal.CategoryUUID),
+			goal.StartDate, goal.Deadline).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.CreateGoal", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	var uuid string
+	if err = r.client.QueryRow(nCtx, sql, i...).Scan(&uuid); err != nil {
+		span.RecordError(err)
+		return "", handleSQLError(err, r.logger)
+	}
+	return uuid, nil
+}
+
+func (r *goalRepository) FindAll(ctx context.Context, userUUID string) ([]entity.Goal, error) {
+	sql, i, err := squirrel.Select(goalColumns).
+		From("stats.goals").
+		Where(squirrel.Eq{"user_id": userUUID}).
+		OrderBy("deadline", "created_at").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindGoals", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	goals := make([]entity.Goal, 0)
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			span.RecordError(err)
+			return nil, handleSQLError(err, r.logger)
+		}
+		goals = append(goals, goal)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	span.SetAttribute("db.rows", len(goals))
+
+	return goals, nil
+}
+
+func (r *goalRepository) FindOne(ctx context.Context, uuid string) (entity.Goal, error) {
+	sql, i, err := squirrel.Select(goalColumns).
+		From("stats.goals").
+		Where(squirrel.Eq{"id": uuid}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return entity.Goal{}, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindGoal", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	goal, err := scanGoal(r.client.QueryRow(nCtx, sql, i...))
+	if err != nil {
+		span.RecordError(err)
+		return goal, handleSQLError(err, r.logger)
+	}
+	return goal, nil
+}
+
+func (r *goalRepository) Update(ctx context.Context, goal entity.Goal) error {
+	sql, i, err := squirrel.Update("stats.goals").
+		Set("user_id", goal.UserUUID).
+		Set("name", goal.Name).
+		Set("target_amount", goal.TargetAmount).
+		Set("rule", string(goal.Rule)).
+		Set("category_id", nullableString(goal.CategoryUUID)).
+		Set("start_date", goal.StartDate).
+		Set("deadline", goal.Deadline).
+		Set("updated_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"id": goal.UUID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+
+	return execAffectingOne(ctx, r.client, r.logger, "repository.UpdateGoal", sql, i)
+}
+
+func (r *goalRepository) Delete(ctx context.Context, uuid string) error {
+	sql, i, err := squirrel.Delete("stats.goals").
+		Where(squirrel.Eq{"id": uuid}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+
+	return execAffectingOne(ctx, r.client, r.logger, "repository.DeleteGoal", sql, i)
+}
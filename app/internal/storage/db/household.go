@@ -0,0 +1,203 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"github.com/Masterminds/squirrel"
+	"stats-service/internal/apperror"
+	"stats-service/internal/domain/entity"
+	"stats-service/internal/domain/service"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/postgresql"
+	"stats-service/pkg/utils"
+)
+
+type householdRepository struct {
+	client postgresql.Client
+	logger *logging.Logger
+}
+
+// NewHouseholdRepository creates a repository for households. Membership decides who may read
+// whose operations, so it is always queried on the primary.
+func NewHouseholdRepository(client postgresql.Client, logger *logging.Logger) service.HouseholdRepository {
+	return &householdRepository{
+		client: client,
+		logger: logger,
+	}
+}
+
+func selectHouseholds() squirrel.SelectBuilder {
+	return squirrel.Select("h.id, h.name, m.user_id, m.role, m.accepted_at IS NOT NULL").
+		From("stats.households h").
+		Join("stats.household_members m ON m.household_id = h.id").
+		OrderBy("h.created_at", "h.id", "m.created_at", "m.user_id").
+		PlaceholderFormat(squirrel.Dollar)
+}
+
+// Create stores the household together with its initial members.
+func (r *householdRepository) Create(ctx context.Context, household entity.Household) (string, error) {
+	sql, i, err := squirrel.Insert("stats.households").
+		Columns("name").
+		Values(household.Name).
+		Suffix("RETURNING id").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return "", fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.CreateHousehold", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	tx, err := r.client.Begin(nCtx)
+	if err != nil {
+		span.RecordError(err)
+		return "", handleSQLError(err, r.logger)
+	}
+	defer func() {
+		_ = tx.Rollback(nCtx)
+	}()
+
+	var uuid string
+	if err = tx.QueryRow(nCtx, sql, i...).Scan(&uuid); err != nil {
+		span.RecordError(err)
+		return "", handleSQLError(err, r.logger)
+	}
+	for _, member := range household.Members {
+		_, err = tx.Exec(nCtx, "INSERT INTO stats.household_members (household_id, user_id, role, accepted_at) "+
+			"VALUES ($1, $2, $3, CASE WHEN $4::boolean THEN now() END)",
+			uuid, member.UserUUID, string(member.Role), member.Accepted)
+		if err != nil {
+			span.RecordError(err)
+			return "", handleSQLError(err, r.logger)
+		}
+	}
+
+	if err = tx.Commit(nCtx); err != nil {
+		span.RecordError(err)
+		return "", handleSQLError(err, r.logger)
+	}
+	return uuid, nil
+}
+
+// FindAll returns the households userUUID is a member of or invited to, with all their members.
+func (r *householdRepository) FindAll(ctx context.Context, userUUID string) ([]entity.Household, error) {
+	qb := selectHouseholds().
+		Where(squirrel.Expr("h.id IN (SELECT household_id FROM stats.household_members WHERE user_id = ?)", userUUID))
+	return r.findMany(ctx, "repository.FindHouseholds", qb)
+}
+
+func (r *householdRepository) FindOne(ctx context.Context, uuid string) (entity.Household, error) {
+	households, err := r.findMany(ctx, "repository.FindHousehold", selectHouseholds().Where(squirrel.Eq{"h.id": uuid}))
+	if err != nil {
+		return entity.Household{}, err
+	}
+	if len(households) == 0 {
+		return entity.Household{}, apperror.ErrNotFound
+	}
+	return households[0], nil
+}
+
+// findMany groups the rows of a selectHouseholds query, which are ordered by household, into households.
+func (r *householdRepository) findMany(ctx context.Context, spanName string, qb squirrel.SelectBuilder) ([]entity.Household, error) {
+	sql, i, err := qb.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, spanName, sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.client.Query(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	households := make([]entity.Household, 0)
+	for rows.Next() {
+		var uuid, name, role string
+		var member entity.HouseholdMember
+		if err = rows.Scan(&uuid, &name, &member.UserUUID, &role, &member.Accepted); err != nil {
+			span.RecordError(err)
+			return nil, handleSQLError(err, r.logger)
+		}
+		member.Role = entity.HouseholdRole(role)
+
+		if len(households) == 0 || households[len(households)-1].UUID != uuid {
+			households = append(households, entity.Household{UUID: uuid, Name: name})
+		}
+		last := &households[len(households)-1]
+		last.Members = append(last.Members, member)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	span.SetAttribute("db.rows", len(households))
+
+	return households, nil
+}
+
+func (r *householdRepository) Delete(ctx context.Context, uuid string) error {
+	sql, i, err := squirrel.Delete("stats.households").
+		Where(squirrel.Eq{"id": uuid}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+
+	return execAffectingOne(ctx, r.client, r.logger, "repository.DeleteHousehold", sql, i)
+}
+
+// SaveMember invites the member to the household or changes the role of an existing member,
+// keeping whether the invitation was accepted.
+func (r *householdRepository) SaveMember(ctx context.Context, householdUUID string, member entity.HouseholdMember) error {
+	sql, i, err := squirrel.Insert("stats.household_members").
+		Columns("household_id", "user_id", "role").
+		Values(householdUUID, member.UserUUID, string(member.Role)).
+		Suffix("ON CONFLICT (household_id, user_id) DO UPDATE SET role = excluded.role").
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+
+	return execAffectingOne(ctx, r.client, r.logger, "repository.SaveHouseholdMember", sql, i)
+}
+
+// AcceptMember accepts the pending invitation of userUUID, ErrNotFound when there is none.
+func (r *householdRepository) AcceptMember(ctx context.Context, householdUUID, userUUID string) error {
+	sql, i, err := squirrel.Update("stats.household_members").
+		Set("accepted_at", squirrel.Expr("now()")).
+		Where(squirrel.Eq{"household_id": householdUUID, "user_id": userUUID, "accepted_at": nil}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+
+	return execAffectingOne(ctx, r.client, r.logger, "repository.AcceptHouseholdMember", sql, i)
+}
+
+func (r *householdRepository) DeleteMember(ctx context.Context, householdUUID, userUUID string) error {
+	sql, i, err := squirrel.Delete("stats.household_members").
+		Where(squirrel.Eq{"household_id": householdUUID, "user_id": userUUID}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+
+	return execAffectingOne(ctx, r.client, r.logger, "repository.DeleteHouseholdMember", sql, i)
+}
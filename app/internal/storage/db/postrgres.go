@@ -14,7 +14,9 @@ import (
 	"stats-service/pkg/api/filter"
 	"stats-service/pkg/logging"
 	"stats-service/pkg/postgresql"
+	"stats-service/pkg/tracing"
 	"stats-service/pkg/utils"
+	"strings"
 	"time"
 )
 
@@ -22,16 +24,63 @@ const queryWaitTime = 5 * time.Second
 
 type repository struct {
 	client postgresql.Client
+	reader postgresql.Client
 	logger *logging.Logger
 }
 
-func NewRepository(client postgresql.Client, logger *logging.Logger) service.Repository {
+// NewRepository creates a repository that sends read-only queries to reader,
+// which may be a read replica, and everything else to client.
+func NewRepository(client, reader postgresql.Client, logger *logging.Logger) service.Repository {
 	return &repository{
 		client: client,
+		reader: reader,
 		logger: logger,
 	}
 }
 
+type snapshotContextKey struct{}
+
+// Snapshot runs fn in a read-only repeatable read transaction on the reader, so that all reads
+// made with the context passed to fn see the same data, e.g. a report and its version.
+func (r *repository) Snapshot(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(snapshotContextKey{}).(pgx.Tx); ok {
+		return fn(ctx)
+	}
+
+	ctx, span := tracing.StartSpan(ctx, "repository.Snapshot")
+	defer span.Finish()
+
+	tx, err := r.reader.Begin(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return handleSQLError(err, r.logger)
+	}
+	defer func() {
+		_ = tx.Rollback(context.Background())
+	}()
+	if _, err = tx.Exec(ctx, "SET TRANSACTION ISOLATION LEVEL REPEATABLE READ, READ ONLY"); err != nil {
+		span.RecordError(err)
+		return handleSQLError(err, r.logger)
+	}
+
+	if err = fn(context.WithValue(ctx, snapshotContextKey{}, tx)); err != nil {
+		return err
+	}
+	if err = tx.Commit(ctx); err != nil {
+		span.RecordError(err)
+		return handleSQLError(err, r.logger)
+	}
+	return nil
+}
+
+// readerFor returns the transaction of the Snapshot ctx belongs to, or the reader outside of one.
+func (r *repository) readerFor(ctx context.Context) postgresql.Client {
+	if tx, ok := ctx.Value(snapshotContextKey{}).(pgx.Tx); ok {
+		return tx
+	}
+	return r.reader
+}
+
 func handleSQLError(err error, logger *logging.Logger) error {
 	if errors.Is(err, pgx.ErrNoRows) {
 		return apperror.ErrNotFound
@@ -54,13 +103,63 @@ func handleSQLError(err error, logger *logging.Logger) error {
 	return err
 }
 
+func explainQuery(ctx context.Context, client postgresql.Client, prefix, sql string, args []interface{}) (string, error) {
+	rows, err := client.Query(ctx, prefix+sql, args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	lines := make([]string, 0)
+	for rows.Next() {
+		var line string
+		if err = rows.Scan(&line); err != nil {
+			return "", err
+		}
+		lines = append(lines, line)
+	}
+	if err = rows.Err(); err != nil {
+		return "", err
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+func startQuerySpan(ctx context.Context, name, sql string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.StartSpan(ctx, name)
+	span.SetAttribute("db.system", "postgresql")
+	span.SetAttribute("db.statement", utils.FormatSQLQuery(sql))
+	return ctx, span
+}
+
+// joinCategories joins the category of every operation as c. Filters on category fields use this alias.
+const joinCategories = "public.categories c ON o.category_id = c.id"
+
+// processFilterOptionsWithSquirrel applies options to a query over public.operations o and joins
+// the categories once when a category field is filtered.
 func processFilterOptionsWithSquirrel(qb squirrel.SelectBuilder, options filter.Options) squirrel.SelectBuilder {
+	return applyFilterOptions(qb, options, false)
+}
+
+// processFilterOptionsWithCategories applies options to a query that already has joinCategories.
+func processFilterOptionsWithCategories(qb squirrel.SelectBuilder, options filter.Options) squirrel.SelectBuilder {
+	return applyFilterOptions(qb, options, true)
+}
+
+func applyFilterOptions(qb squirrel.SelectBuilder, options filter.Options, categoriesJoined bool) squirrel.SelectBuilder {
 	fields := options.Fields()
 
 	for _, field := range fields {
+		switch field.Name {
+		case entity.UserUUID, entity.CategoryName, entity.TypeOfCategory, entity.CategoryUUID:
+			if !categoriesJoined {
+				qb = qb.Join(joinCategories)
+				categoriesJoined = true
+			}
+		}
+
 		switch field.Name {
 		case entity.UserUUID:
-			qb = qb.Join("public.categories c1 ON o.category_id = c1.id").Where(squirrel.Eq{"c1.user_id": field.Values[0]})
+			qb = qb.Where(squirrel.Eq{"c.user_id": field.Values})
 
 		case entity.CategoryName:
 			for _, value := range field.Values {
@@ -68,32 +167,31 @@ func processFilterOptionsWithSquirrel(qb squirrel.SelectBuilder, options filter.
 			}
 
 		case entity.TypeOfCategory:
-			qb = qb.Join("public.categories c2 ON o.category_id = c2.id").Where(squirrel.Eq{"c2.type": field.Values})
+			qb = qb.Where(squirrel.Eq{"c.type": field.Values})
 
 		case entity.CategoryUUID:
-			qb = qb.Join("public.categories c ON o.category_id = c.id").Where(squirrel.Eq{"c.id": field.Values})
-
+			qb = qb.Where(squirrel.Eq{"c.id": field.Values})
 		case entity.Description:
 			for _, value := range field.Values {
-				qb = qb.Where(squirrel.Like{"description": "%" + value + "%"})
+				qb = qb.Where(squirrel.Like{"o.description": "%" + value + "%"})
 			}
 
 		case entity.MoneySum:
 			switch field.Operator {
 			case filter.OperatorEqual:
-				qb = qb.Where(squirrel.Eq{field.Name: field.Values})
+				qb = qb.Where(squirrel.Eq{"o." + field.Name: field.Values})
 			case filter.OperatorNotEqual:
-				qb = qb.Where(squirrel.NotEq{field.Name: field.Values})
+				qb = qb.Where(squirrel.NotEq{"o." + field.Name: field.Values})
 			case filter.OperatorLowerThan:
-				qb = qb.Where(squirrel.Lt{field.Name: field.Values[0]})
+				qb = qb.Where(squirrel.Lt{"o." + field.Name: field.Values[0]})
 			case filter.OperatorLowerThanEqual:
-				qb = qb.Where(squirrel.LtOrEq{field.Name: field.Values[0]})
+				qb = qb.Where(squirrel.LtOrEq{"o." + field.Name: field.Values[0]})
 			case filter.OperatorGreaterThan:
-				qb = qb.Where(squirrel.Gt{field.Name: field.Values[0]})
+				qb = qb.Where(squirrel.Gt{"o." + field.Name: field.Values[0]})
 			case filter.OperatorGreaterThanEqual:
-				qb = qb.Where(squirrel.GtOrEq{field.Name: field.Values[0]})
+				qb = qb.Where(squirrel.GtOrEq{"o." + field.Name: field.Values[0]})
 			case filter.OperatorBetween:
-				qb = qb.Where(squirrel.Expr(fmt.Sprintf("%s BETWEEN ? AND ?", field.Name),
+				qb = qb.Where(squirrel.Expr(fmt.Sprintf("o.%s BETWEEN ? AND ?", field.Name),
 					field.Values[0], field.Values[1]))
 			}
 
@@ -102,7 +200,7 @@ func processFilterOptionsWithSquirrel(qb squirrel.SelectBuilder, options filter.
 				field.Values = append(field.Values, field.Values[0])
 			}
 
-			qb = qb.Where(squirrel.Expr(fmt.Sprintf("%s BETWEEN ? AND ?", field.Name),
+			qb = qb.Where(squirrel.Expr(fmt.Sprintf("o.%s BETWEEN ? AND ?", field.Name),
 				fmt.Sprintf("%s 00:00:00", field.Values[0]), fmt.Sprintf("%s 23:59:59", field.Values[1])))
 		}
 	}
@@ -111,8 +209,7 @@ func processFilterOptionsWithSquirrel(qb squirrel.SelectBuilder, options filter.
 	return qb
 }
 
-func (r *repository) FindAll(ctx context.Context, sortOptions sorting.SortOptions, filterOptions filter.Options) ([]entity.Operation, error) {
-	var err error
+func buildFindAllQuery(sortOptions sorting.SortOptions, filterOptions filter.Options) (string, []interface{}, error) {
 	qb := squirrel.Select("o.id, o.category_id, o.money_sum, o.description, o.date_time").From("public.operations o")
 
 	if sortOptions != nil {
@@ -125,14 +222,26 @@ func (r *repository) FindAll(ctx context.Context, sortOptions sorting.SortOption
 
 	sql, i, err := qb.ToSql()
 	if err != nil {
-		return nil, fmt.Errorf("failed to build query into a SQL string: %w", err)
+		return "", nil, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	return sql, i, nil
+}
+
+func (r *repository) FindAll(ctx context.Context, sortOptions sorting.SortOptions, filterOptions filter.Options) ([]entity.Operation, error) {
+	sql, i, err := buildFindAllQuery(sortOptions, filterOptions)
+	if err != nil {
+		return nil, err
 	}
-	r.logger.Tracef(fmt.Sprintf("SQL Query: %s", utils.FormatSQLQuery(sql)))
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindAll", sql)
+	defer span.Finish()
 
 	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
 	defer cancel()
-	rows, err := r.client.Query(nCtx, sql, i...)
+	rows, err := r.readerFor(nCtx).Query(nCtx, sql, i...)
 	if err != nil {
+		span.RecordError(err)
 		return nil, handleSQLError(err, r.logger)
 	}
 	defer rows.Close()
@@ -142,14 +251,602 @@ func (r *repository) FindAll(ctx context.Context, sortOptions sorting.SortOption
 		var op entity.Operation
 		err = rows.Scan(&op.UUID, &op.CategoryUUID, &op.MoneySum, &op.Description, &op.DateTime)
 		if err != nil {
+			span.RecordError(err)
 			return nil, handleSQLError(err, r.logger)
 		}
 		operations = append(operations, op)
 	}
 
 	if err = rows.Err(); err != nil {
+		span.RecordError(err)
 		return nil, handleSQLError(err, r.logger)
 	}
+	span.SetAttribute("db.rows", len(operations))
 
 	return operations, nil
 }
+
+func (r *repository) FindVersion(ctx context.Context, filterOptions filter.Options) (entity.ReportVersion, error) {
+	var version entity.ReportVersion
+	// xmin changes on every insert and update of a row. Instead of max(xmin), which is not monotonic
+	// across transaction id wraparound, the row version is a fingerprint summing a hash of the id and
+	// xmin of every matching operation and of its category, so it changes when an operation is
+	// added, edited or deleted and when its category is renamed or retyped.
+	qb := squirrel.Select("count(*)", "max(o.date_time)",
+		"coalesce(sum(hashtext(o.id::text || ':' || o.xmin::text || ':' || c.xmin::text)), 0)").
+		From("public.operations o").
+		Join(joinCategories)
+
+	if filterOptions != nil {
+		qb = processFilterOptionsWithCategories(qb, filterOptions)
+	}
+
+	sql, i, err := qb.ToSql()
+	if err != nil {
+		return version, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindVersion", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	var lastDateTime *time.Time
+	err = r.readerFor(nCtx).QueryRow(nCtx, sql, i...).Scan(&version.OperationsCount, &lastDateTime, &version.RowVersion)
+	if err != nil {
+		span.RecordError(err)
+		return version, handleSQLError(err, r.logger)
+	}
+	if lastDateTime != nil {
+		version.LastDateTime = *lastDateTime
+	}
+
+	return version, nil
+}
+
+func (r *repository) FindCategory(ctx context.Context, uuid string) (entity.Category, error) {
+	var category entity.Category
+	sql, i, err := squirrel.Select("c.id", "c.user_id", "c.name", "c.type").
+		From("public.categories c").
+		Where(squirrel.Eq{"c.id": uuid}).
+		PlaceholderFormat(squirrel.Dollar).
+		ToSql()
+	if err != nil {
+		return category, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindCategory", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	err = r.readerFor(nCtx).QueryRow(nCtx, sql, i...).Scan(&category.UUID, &category.UserUUID, &category.Name, &category.Type)
+	if err != nil {
+		span.RecordError(err)
+		return category, handleSQLError(err, r.logger)
+	}
+	return category, nil
+}
+
+func (r *repository) ExplainFindAll(ctx context.Context, sortOptions sorting.SortOptions, filterOptions filter.Options) (entity.QueryPlan, error) {
+	var queryPlan entity.QueryPlan
+	sql, i, err := buildFindAllQuery(sortOptions, filterOptions)
+	if err != nil {
+		return queryPlan, err
+	}
+
+	queryPlan.SQL = utils.FormatSQLQuery(sql)
+	queryPlan.Args = make([]string, 0, len(i))
+	for n, arg := range i {
+		queryPlan.Args = append(queryPlan.Args, fmt.Sprintf("$%d=%v", n+1, arg))
+	}
+
+	ctx, span := startQuerySpan(ctx, "repository.ExplainFindAll", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	// plain EXPLAIN only plans the statement, it is not executed
+	queryPlan.Plan, err = explainQuery(nCtx, r.reader, "EXPLAIN ", sql, i)
+	if err != nil {
+		span.RecordError(err)
+		return queryPlan, handleSQLError(err, r.logger)
+	}
+
+	return queryPlan, nil
+}
+
+func (r *repository) SumOperations(ctx context.Context, scope entity.SpendingScope, from, to time.Time) (float64, error) {
+	qb := squirrel.Select("coalesce(sum(o.money_sum), 0)::float8").
+		From("public.operations o").
+		Join(joinCategories).
+		Where(squirrel.Eq{"c.user_id": scope.UserUUID}).
+		Where(squirrel.GtOrEq{"o.date_time": from}).
+		Where(squirrel.Lt{"o.date_time": to})
+	if scope.CategoryUUID != "" {
+		qb = qb.Where(squirrel.Eq{"c.id": scope.CategoryUUID})
+	}
+	if scope.CategoryType != "" {
+		qb = qb.Where(squirrel.Eq{"c.type": string(scope.CategoryType)})
+	}
+
+	sql, i, err := qb.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.SumOperations", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+
+	var sum float64
+	if err = r.readerFor(nCtx).QueryRow(nCtx, sql, i...).Scan(&sum); err != nil {
+		span.RecordError(err)
+		return 0, handleSQLError(err, r.logger)
+	}
+	return sum, nil
+}
+
+// FindAllWithType returns the operations matching filterOptions in chronological order
+// together with the type of their category.
+func (r *repository) FindAllWithType(ctx context.Context, filterOptions filter.Options) ([]entity.TypedOperation, error) {
+	qb := squirrel.Select("o.id, o.category_id, o.money_sum, o.description, o.date_time, c.type").
+		From("public.operations o").
+		Join(joinCategories).
+		OrderBy("o.date_time ASC")
+
+	if filterOptions != nil {
+		qb = processFilterOptionsWithCategories(qb, filterOptions)
+	}
+
+	sql, i, err := qb.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindAllWithType", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.readerFor(nCtx).Query(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	operations := make([]entity.TypedOperation, 0)
+	for rows.Next() {
+		var op entity.TypedOperation
+		var categoryType string
+		err = rows.Scan(&op.UUID, &op.CategoryUUID, &op.MoneySum, &op.Description, &op.DateTime, &categoryType)
+		if err != nil {
+			span.RecordError(err)
+			return nil, handleSQLError(err, r.logger)
+		}
+		op.CategoryType = entity.CategoryType(categoryType)
+		operations = append(operations, op)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	span.SetAttribute("db.rows", len(operations))
+
+	return operations, nil
+}
+
+// SumByCategory aggregates the operations matching filterOptions in [from, to) per category.
+func (r *repository) SumByCategory(ctx context.Context, filterOptions filter.Options, from, to time.Time) ([]entity.CategoryTotal, error) {
+	qb := squirrel.Select("c.id, c.user_id::text, c.name, c.type, coalesce(sum(o.money_sum), 0)::float8, count(*)").
+		From("public.operations o").
+		Join(joinCategories).
+		Where(squirrel.GtOrEq{"o.date_time": from}).
+		Where(squirrel.Lt{"o.date_time": to}).
+		GroupBy("c.id", "c.user_id", "c.name", "c.type")
+
+	if filterOptions != nil {
+		qb = processFilterOptionsWithCategories(qb, filterOptions)
+	}
+
+	sql, i, err := qb.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.SumByCategory", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.readerFor(nCtx).Query(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	totals := make([]entity.CategoryTotal, 0)
+	for rows.Next() {
+		var total entity.CategoryTotal
+		var categoryType string
+		err = rows.Scan(&total.CategoryUUID, &total.UserUUID, &total.CategoryName, &categoryType, &total.Total, &total.Count)
+		if err != nil {
+			span.RecordError(err)
+			return nil, handleSQLError(err, r.logger)
+		}
+		total.CategoryType = entity.CategoryType(categoryType)
+		totals = append(totals, total)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	span.SetAttribute("db.rows", len(totals))
+
+	return totals, nil
+}
+
+// FindBalance returns the income and expense of the operations matching filterOptions per interval,
+// with the running balance computed by a window function over the interval totals.
+// Intervals without operations are not returned.
+func (r *repository) FindBalance(ctx context.Context, filterOptions filter.Options, interval string) ([]entity.BalancePoint, error) {
+	// interval is one of the entity.Interval constants, never user input, so it can be inlined;
+	// a placeholder would make the grouped and the selected expressions differ
+	bucket := fmt.Sprintf("date_trunc('%s', o.date_time)", interval)
+	qb := squirrel.Select(bucket,
+		"coalesce(sum(o.money_sum) FILTER (WHERE c.type = 'Income'), 0)::float8",
+		"coalesce(sum(o.money_sum) FILTER (WHERE c.type <> 'Income'), 0)::float8",
+		fmt.Sprintf("sum(sum(CASE WHEN c.type = 'Income' THEN o.money_sum ELSE -o.money_sum END)) "+
+			"OVER (ORDER BY %s)::float8", bucket)).
+		From("public.operations o").
+		Join(joinCategories).
+		GroupBy(bucket).
+		OrderBy(bucket)
+
+	if filterOptions != nil {
+		qb = processFilterOptionsWithCategories(qb, filterOptions)
+	}
+
+	sql, i, err := qb.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindBalance", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.readerFor(nCtx).Query(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	points := make([]entity.BalancePoint, 0)
+	for rows.Next() {
+		var point entity.BalancePoint
+		if err = rows.Scan(&point.Date, &point.Income, &point.Expense, &point.Balance); err != nil {
+			span.RecordError(err)
+			return nil, handleSQLError(err, r.logger)
+		}
+		point.Net = point.Income - point.Expense
+		points = append(points, point)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	span.SetAttribute("db.rows", len(points))
+
+	return points, nil
+}
+
+// FindDistribution returns summary statistics of the money sums of the operations matching filterOptions.
+func (r *repository) FindDistribution(ctx context.Context, filterOptions filter.Options) (entity.Distribution, error) {
+	var distribution entity.Distribution
+	qb := squirrel.Select("count(*)",
+		"coalesce(avg(o.money_sum), 0)::float8",
+		"coalesce(stddev_samp(o.money_sum), 0)::float8",
+		"coalesce(min(o.money_sum), 0)::float8",
+		"coalesce(max(o.money_sum), 0)::float8",
+		"coalesce(percentile_cont(0.5) WITHIN GROUP (ORDER BY o.money_sum::float8), 0)",
+		"coalesce(percentile_cont(0.25) WITHIN GROUP (ORDER BY o.money_sum::float8), 0)",
+		"coalesce(percentile_cont(0.75) WITHIN GROUP (ORDER BY o.money_sum::float8), 0)",
+		"coalesce(percentile_cont(0.9) WITHIN GROUP (ORDER BY o.money_sum::float8), 0)",
+		"coalesce(percentile_cont(0.99) WITHIN GROUP (ORDER BY o.money_sum::float8), 0)").
+		From("public.operations o")
+
+	if filterOptions != nil {
+		qb = processFilterOptionsWithSquirrel(qb, filterOptions)
+	}
+
+	sql, i, err := qb.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return distribution, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindDistribution", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	err = r.readerFor(nCtx).QueryRow(nCtx, sql, i...).Scan(&distribution.Count, &distribution.Mean, &distribution.StdDev,
+		&distribution.Min, &distribution.Max, &distribution.Median, &distribution.P25, &distribution.P75,
+		&distribution.P90, &distribution.P99)
+	if err != nil {
+		span.RecordError(err)
+		return distribution, handleSQLError(err, r.logger)
+	}
+	return distribution, nil
+}
+
+// CountByBuckets counts and sums the operations matching filterOptions in the buckets between
+// consecutive edges, which must be ascending. Amounts outside the edges fall into the first or
+// the last bucket.
+func (r *repository) CountByBuckets(ctx context.Context, filterOptions filter.Options, edges []float64) ([]entity.HistogramBucket, error) {
+	n := len(edges) - 1
+	qb := squirrel.Select().
+		Column(squirrel.Expr("greatest(least(width_bucket(o.money_sum::float8, ?::float8[]), ?), 1)", edges, n)).
+		Columns("count(*)", "coalesce(sum(o.money_sum), 0)::float8").
+		From("public.operations o").
+		GroupBy("1").
+		OrderBy("1")
+
+	if filterOptions != nil {
+		qb = processFilterOptionsWithSquirrel(qb, filterOptions)
+	}
+
+	sql, i, err := qb.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.CountByBuckets", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.readerFor(nCtx).Query(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	buckets := make([]entity.HistogramBucket, n)
+	for k := range buckets {
+		buckets[k].From = edges[k]
+		buckets[k].To = edges[k+1]
+	}
+	for rows.Next() {
+		var index int
+		var count int64
+		var sum float64
+		if err = rows.Scan(&index, &count, &sum); err != nil {
+			span.RecordError(err)
+			return nil, handleSQLError(err, r.logger)
+		}
+		buckets[index-1].Count = count
+		buckets[index-1].Sum = sum
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	span.SetAttribute("db.rows", n)
+
+	return buckets, nil
+}
+
+// FindTop returns the first filterOptions.Limit() categories, normalized descriptions or operations
+// matching filterOptions ranked by metric, together with the sum and the count of all of them.
+// Descriptions are normalized the same way as for recurring operations: lower case letters only.
+func (r *repository) FindTop(ctx context.Context, filterOptions filter.Options, by, metric string) ([]entity.TopItem, float64, int64, error) {
+	var qb squirrel.SelectBuilder
+	switch by {
+	case entity.TopByCategory:
+		qb = squirrel.Select("c.id::text, c.name", "sum(o.money_sum)::float8 AS total", "count(*) AS cnt",
+			"sum(sum(o.money_sum)) OVER ()::float8", "sum(count(*)) OVER ()::bigint").
+			From("public.operations o").
+			Join(joinCategories).
+			GroupBy("c.id", "c.name")
+	case entity.TopByDescription:
+		qb = squirrel.Select("btrim(regexp_replace(lower(o.description), '[^[:alpha:]]+', ' ', 'g')) AS merchant",
+			"min(o.description)", "sum(o.money_sum)::float8 AS total", "count(*) AS cnt",
+			"sum(sum(o.money_sum)) OVER ()::float8", "sum(count(*)) OVER ()::bigint").
+			From("public.operations o").
+			GroupBy("merchant")
+	default:
+		qb = squirrel.Select("o.id::text, o.description", "o.money_sum::float8 AS total", "1 AS cnt",
+			"sum(o.money_sum) OVER ()::float8", "count(*) OVER ()").
+			From("public.operations o")
+	}
+
+	if metric == entity.TopMetricCount {
+		qb = qb.OrderBy("cnt DESC", "total DESC", "1")
+	} else {
+		qb = qb.OrderBy("total DESC", "cnt DESC", "1")
+	}
+
+	if filterOptions != nil {
+		if by == entity.TopByCategory {
+			qb = processFilterOptionsWithCategories(qb, filterOptions)
+		} else {
+			qb = processFilterOptionsWithSquirrel(qb, filterOptions)
+		}
+		qb = qb.Limit(uint64(filterOptions.Limit()))
+	}
+
+	sql, i, err := qb.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, 0, 0, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindTop", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.readerFor(nCtx).Query(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, 0, 0, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	items := make([]entity.TopItem, 0)
+	var total float64
+	var count int64
+	for rows.Next() {
+		var item entity.TopItem
+		if err = rows.Scan(&item.Key, &item.Label, &item.Total, &item.Count, &total, &count); err != nil {
+			span.RecordError(err)
+			return nil, 0, 0, handleSQLError(err, r.logger)
+		}
+		items = append(items, item)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, 0, 0, handleSQLError(err, r.logger)
+	}
+	span.SetAttribute("db.rows", len(items))
+
+	return items, total, count, nil
+}
+
+// FindHeatmap counts and sums the operations matching filterOptions by day of week and hour of
+// date_time, which holds UTC wall time, converted to timezone.
+func (r *repository) FindHeatmap(ctx context.Context, filterOptions filter.Options, timezone string) (entity.Heatmap, error) {
+	heatmap := entity.Heatmap{Timezone: timezone}
+	qb := squirrel.Select().
+		Column(squirrel.Expr("timezone(?, timezone('UTC', o.date_time)) AS local_time", timezone)).
+		From("public.operations o")
+
+	if filterOptions != nil {
+		qb = processFilterOptionsWithSquirrel(qb, filterOptions)
+	}
+	qb = qb.Columns("o.money_sum")
+
+	// the conversion is done once in the subquery so the timezone is bound to a single placeholder
+	qb = squirrel.Select("extract(dow FROM t.local_time)::int", "extract(hour FROM t.local_time)::int",
+		"count(*)", "coalesce(sum(t.money_sum), 0)::float8").
+		FromSelect(qb, "t").
+		GroupBy("1", "2")
+
+	sql, i, err := qb.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return heatmap, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.FindHeatmap", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.readerFor(nCtx).Query(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return heatmap, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	cells := 0
+	for rows.Next() {
+		var weekday, hour int
+		var count int64
+		var sum float64
+		if err = rows.Scan(&weekday, &hour, &count, &sum); err != nil {
+			span.RecordError(err)
+			return heatmap, handleSQLError(err, r.logger)
+		}
+		heatmap.Counts[weekday][hour] = count
+		heatmap.Sums[weekday][hour] = sum
+		cells++
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return heatmap, handleSQLError(err, r.logger)
+	}
+	span.SetAttribute("db.rows", cells)
+
+	return heatmap, nil
+}
+
+// SumByUser returns the income, expense and count of the operations matching filterOptions per
+// owner of the category.
+func (r *repository) SumByUser(ctx context.Context, filterOptions filter.Options) ([]entity.MemberTotal, error) {
+	qb := squirrel.Select("c.user_id::text",
+		"coalesce(sum(o.money_sum) FILTER (WHERE c.type = 'Income'), 0)::float8",
+		"coalesce(sum(o.money_sum) FILTER (WHERE c.type <> 'Income'), 0)::float8",
+		"count(*)").
+		From("public.operations o").
+		Join(joinCategories).
+		GroupBy("c.user_id").
+		OrderBy("c.user_id")
+
+	if filterOptions != nil {
+		qb = processFilterOptionsWithCategories(qb, filterOptions)
+	}
+
+	sql, i, err := qb.PlaceholderFormat(squirrel.Dollar).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query into a SQL string: %w", err)
+	}
+	logging.LoggerFromContext(ctx, r.logger).Tracef("SQL Query: %s", utils.FormatSQLQuery(sql))
+
+	ctx, span := startQuerySpan(ctx, "repository.SumByUser", sql)
+	defer span.Finish()
+
+	nCtx, cancel := context.WithTimeout(ctx, queryWaitTime)
+	defer cancel()
+	rows, err := r.readerFor(nCtx).Query(nCtx, sql, i...)
+	if err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	defer rows.Close()
+
+	totals := make([]entity.MemberTotal, 0)
+	for rows.Next() {
+		var total entity.MemberTotal
+		if err = rows.Scan(&total.UserUUID, &total.Income, &total.Expense, &total.Count); err != nil {
+			span.RecordError(err)
+			return nil, handleSQLError(err, r.logger)
+		}
+		total.Net = total.Income - total.Expense
+		totals = append(totals, total)
+	}
+
+	if err = rows.Err(); err != nil {
+		span.RecordError(err)
+		return nil, handleSQLError(err, r.logger)
+	}
+	span.SetAttribute("db.rows", len(totals))
+
+	return totals, nil
+}
@@ -0,0 +1,280 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"stats-service/internal/domain/entity"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/postgresql"
+	"stats-service/pkg/utils"
+	"strings"
+	"sync"
+	"time"
+)
+
+const explainWaitTime = 30 * time.Second
+
+type SlowQueryOptions struct {
+	Threshold        time.Duration
+	ExplainThreshold time.Duration
+	RedactArgs       bool
+	MaxEntries       int
+}
+
+// SlowQueryLog records queries that take longer than Threshold on the clients it wraps.
+// Read queries slower than ExplainThreshold are additionally explained with
+// EXPLAIN (ANALYZE, BUFFERS) in the background, one at a time. The newest MaxEntries
+// records are kept in memory.
+type SlowQueryLog struct {
+	options SlowQueryOptions
+	logger  *logging.Logger
+
+	mu      sync.Mutex
+	entries []entity.SlowQuery
+	nextID  int64
+
+	explainSem chan struct{}
+}
+
+func NewSlowQueryLog(options SlowQueryOptions, logger *logging.Logger) *SlowQueryLog {
+	return &SlowQueryLog{
+		options:    options,
+		logger:     logger,
+		explainSem: make(chan struct{}, 1),
+	}
+}
+
+// Wrap returns a client that reports its queries to the log.
+func (l *SlowQueryLog) Wrap(client postgresql.Client) postgresql.Client {
+	return &slowQueryClient{
+		Client:    client,
+		explainer: client,
+		log:       l,
+	}
+}
+
+type slowQueryClient struct {
+	postgresql.Client
+	// explainer runs the EXPLAIN of slow queries, the wrapped client unless that is a transaction
+	explainer postgresql.Client
+	log       *SlowQueryLog
+}
+
+// Begin starts a transaction whose queries are reported as well. Their EXPLAIN runs outside
+// of the transaction, which may be finished by then.
+func (c *slowQueryClient) Begin(ctx context.Context) (pgx.Tx, error) {
+	tx, err := c.Client.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &slowQueryTx{Tx: tx, observed: &slowQueryClient{Client: tx, explainer: c.explainer, log: c.log}}, nil
+}
+
+type slowQueryTx struct {
+	pgx.Tx
+	observed *slowQueryClient
+}
+
+func (t *slowQueryTx) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	return t.observed.Exec(ctx, sql, arguments...)
+}
+
+func (t *slowQueryTx) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return t.observed.Query(ctx, sql, args...)
+}
+
+func (t *slowQueryTx) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return t.observed.QueryRow(ctx, sql, args...)
+}
+
+func (c *slowQueryClient) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	start := time.Now()
+	tag, err := c.Client.Exec(ctx, sql, arguments...)
+	c.log.observe(ctx, c.explainer, sql, arguments, time.Since(start), tag.RowsAffected(), err)
+	return tag, err
+}
+
+func (c *slowQueryClient) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	start := time.Now()
+	rows, err := c.Client.Query(ctx, sql, args...)
+	if err != nil {
+		c.log.observe(ctx, c.explainer, sql, args, time.Since(start), 0, err)
+		return rows, err
+	}
+	return &observedRows{Rows: rows, onClose: func(count int64) {
+		c.log.observe(ctx, c.explainer, sql, args, time.Since(start), count, rows.Err())
+	}}, nil
+}
+
+func (c *slowQueryClient) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	start := time.Now()
+	row := c.Client.QueryRow(ctx, sql, args...)
+	return observedRow(func(dest ...interface{}) error {
+		err := row.Scan(dest...)
+		switch {
+		case err == nil:
+			c.log.observe(ctx, c.explainer, sql, args, time.Since(start), 1, nil)
+		case errors.Is(err, pgx.ErrNoRows):
+			c.log.observe(ctx, c.explainer, sql, args, time.Since(start), 0, nil)
+		default:
+			c.log.observe(ctx, c.explainer, sql, args, time.Since(start), 0, err)
+		}
+		return err
+	})
+}
+
+// SlowQueries returns the recorded slow queries, newest first.
+func (l *SlowQueryLog) SlowQueries() []entity.SlowQuery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make([]entity.SlowQuery, 0, len(l.entries))
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		result = append(result, l.entries[i])
+	}
+	return result
+}
+
+func (l *SlowQueryLog) observe(ctx context.Context, client postgresql.Client, sql string, args []interface{},
+	duration time.Duration, rows int64, err error) {
+	if l.options.Threshold <= 0 || duration < l.options.Threshold {
+		return
+	}
+
+	query := entity.SlowQuery{
+		SQL:        utils.FormatSQLQuery(sql),
+		Args:       l.formatArgs(args),
+		DurationMs: float64(duration.Microseconds()) / 1000,
+		Rows:       rows,
+		StartedAt:  time.Now().Add(-duration),
+		RequestID:  logging.RequestIDFromContext(ctx),
+	}
+	if err != nil {
+		query.Error = err.Error()
+	}
+
+	logging.LoggerFromContext(ctx, l.logger).
+		GetLoggerWithField("duration", duration.String()).
+		GetLoggerWithField("rows", rows).
+		GetLoggerWithField("args", strings.Join(query.Args, ", ")).
+		Warnf("slow SQL query: %s", query.SQL)
+
+	id := l.store(query)
+
+	if l.options.ExplainThreshold > 0 && duration >= l.options.ExplainThreshold && err == nil && isReadQuery(sql) {
+		select {
+		case l.explainSem <- struct{}{}:
+			go func() {
+				defer func() { <-l.explainSem }()
+				l.explain(client, id, sql, args)
+			}()
+		default:
+			l.logger.Debugf("skipping EXPLAIN of slow query %d, another one is running", id)
+		}
+	}
+}
+
+func (l *SlowQueryLog) store(query entity.SlowQuery) int64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.nextID++
+	query.ID = l.nextID
+	l.entries = append(l.entries, query)
+	if l.options.MaxEntries > 0 && len(l.entries) > l.options.MaxEntries {
+		l.entries = l.entries[len(l.entries)-l.options.MaxEntries:]
+	}
+	return query.ID
+}
+
+func (l *SlowQueryLog) explain(client postgresql.Client, id int64, sql string, args []interface{}) {
+	ctx, cancel := context.WithTimeout(context.Background(), explainWaitTime)
+	defer cancel()
+
+	// EXPLAIN ANALYZE executes the statement once more, so it runs in a read-only transaction
+	// that is always rolled back, in case a function called by the query writes
+	tx, err := client.Begin(ctx)
+	if err != nil {
+		l.logger.Errorf("failed to explain slow query %d: %v", id, err)
+		return
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	if _, err = tx.Exec(ctx, "SET TRANSACTION READ ONLY"); err != nil {
+		l.logger.Errorf("failed to explain slow query %d: %v", id, err)
+		return
+	}
+
+	plan, err := explainQuery(ctx, tx, "EXPLAIN (ANALYZE, BUFFERS) ", sql, args)
+	if err != nil {
+		l.logger.Errorf("failed to explain slow query %d: %v", id, err)
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.entries {
+		if l.entries[i].ID == id {
+			l.entries[i].Plan = plan
+			return
+		}
+	}
+}
+
+func (l *SlowQueryLog) formatArgs(args []interface{}) []string {
+	result := make([]string, 0, len(args))
+	for i, arg := range args {
+		if l.options.RedactArgs {
+			result = append(result, fmt.Sprintf("$%d=<%T redacted>", i+1, arg))
+		} else {
+			result = append(result, fmt.Sprintf("$%d=%v", i+1, arg))
+		}
+	}
+	return result
+}
+
+// isReadQuery guards EXPLAIN ANALYZE, which executes the statement once more. Only plain SELECT
+// statements qualify: a WITH query may contain a data-modifying CTE.
+func isReadQuery(sql string) bool {
+	return strings.HasPrefix(strings.ToUpper(strings.TrimSpace(sql)), "SELECT")
+}
+
+type observedRows struct {
+	pgx.Rows
+	count   int64
+	onClose func(count int64)
+	closed  bool
+}
+
+func (r *observedRows) Next() bool {
+	next := r.Rows.Next()
+	if next {
+		r.count++
+	} else {
+		r.finish()
+	}
+	return next
+}
+
+func (r *observedRows) Close() {
+	r.Rows.Close()
+	r.finish()
+}
+
+func (r *observedRows) finish() {
+	if r.closed {
+		return
+	}
+	r.closed = true
+	r.onClose(r.count)
+}
+
+type observedRow func(dest ...interface{}) error
+
+func (r observedRow) Scan(dest ...interface{}) error {
+	return r(dest...)
+}
@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"sort"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/postgresql"
+	"strconv"
+	"strings"
+)
+
+// lockID is an arbitrary key for pg_advisory_xact_lock, so that concurrently starting
+// instances do not apply the same migration twice.
+const lockID = 7_310_042_117
+
+//go:embed sql/*.sql
+var files embed.FS
+
+type migration struct {
+	version int
+	name    string
+	sql     string
+}
+
+// Migrate applies the embedded migrations that have not been applied yet, in version order
+// and in a single transaction. Files are named <version>_<name>.sql.
+func Migrate(ctx context.Context, client postgresql.Client, logger *logging.Logger) error {
+	migrations, err := load()
+	if err != nil {
+		return err
+	}
+
+	tx, err := client.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin migration transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	if _, err = tx.Exec(ctx, "SELECT pg_advisory_xact_lock($1)", lockID); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		CREATE SCHEMA IF NOT EXISTS stats;
+		CREATE TABLE IF NOT EXISTS stats.schema_migrations (
+			version    integer PRIMARY KEY,
+			name       text NOT NULL,
+			applied_at timestamptz NOT NULL DEFAULT now()
+		)`)
+	if err != nil {
+		return fmt.Errorf("failed to create migrations table: %w", err)
+	}
+
+	applied := make(map[int]bool)
+	rows, err := tx.Query(ctx, "SELECT version FROM stats.schema_migrations")
+	if err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	for rows.Next() {
+		var version int
+		if err = rows.Scan(&version); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read applied migrations: %w", err)
+		}
+		applied[version] = true
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		if applied[m.version] {
+			continue
+		}
+
+		logger.Infof("applying migration %d_%s", m.version, m.name)
+		if _, err = tx.Exec(ctx, m.sql); err != nil {
+			return fmt.Errorf("failed to apply migration %d_%s: %w", m.version, m.name, err)
+		}
+		_, err = tx.Exec(ctx, "INSERT INTO stats.schema_migrations (version, name) VALUES ($1, $2)",
+			m.version, m.name)
+		if err != nil {
+			return fmt.Errorf("failed to record migration %d_%s: %w", m.version, m.name, err)
+		}
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit migrations: %w", err)
+	}
+	return nil
+}
+
+func load() ([]migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	migrations := make([]migration, 0, len(entries))
+	for _, entry := range entries {
+		base := strings.TrimSuffix(entry.Name(), ".sql")
+		versionPart, name, found := strings.Cut(base, "_")
+		version, err := strconv.Atoi(versionPart)
+		if !found || err != nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+
+		sql, err := fs.ReadFile(files, "sql/"+entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+		migrations = append(migrations, migration{version: version, name: name, sql: string(sql)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+	return migrations, nil
+}
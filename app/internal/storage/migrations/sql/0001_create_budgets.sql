@@ -0,0 +1,16 @@
+CREATE TABLE stats.budgets (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       uuid           NOT NULL,
+    category_id   uuid,
+    category_type text,
+    amount        numeric(14, 2) NOT NULL CHECK (amount > 0),
+    period        text           NOT NULL CHECK (period IN ('monthly', 'weekly', 'custom')),
+    start_date    date,
+    end_date      date,
+    created_at    timestamptz    NOT NULL DEFAULT now(),
+    updated_at    timestamptz    NOT NULL DEFAULT now(),
+    CHECK ((category_id IS NULL) <> (category_type IS NULL)),
+    CHECK (period <> 'custom' OR (start_date IS NOT NULL AND end_date IS NOT NULL AND start_date <= end_date))
+);
+
+CREATE INDEX budgets_user_id_idx ON stats.budgets (user_id);
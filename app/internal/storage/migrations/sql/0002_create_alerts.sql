@@ -0,0 +1,28 @@
+CREATE TABLE stats.alert_rules (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       uuid           NOT NULL,
+    kind          text           NOT NULL CHECK (kind IN ('threshold', 'budget_percent', 'growth')),
+    category_id   uuid,
+    category_type text,
+    budget_id     uuid REFERENCES stats.budgets (id) ON DELETE CASCADE,
+    period        text CHECK (period IN ('monthly', 'weekly')),
+    threshold     numeric(14, 2) NOT NULL CHECK (threshold > 0),
+    webhook_url   text           NOT NULL,
+    enabled       boolean        NOT NULL DEFAULT true,
+    created_at    timestamptz    NOT NULL DEFAULT now(),
+    updated_at    timestamptz    NOT NULL DEFAULT now(),
+    CHECK (kind <> 'budget_percent' OR budget_id IS NOT NULL),
+    CHECK (kind = 'budget_percent' OR period IS NOT NULL)
+);
+
+CREATE INDEX alert_rules_user_id_idx ON stats.alert_rules (user_id);
+
+-- one row per rule: the period it was last evaluated for and whether a notification was delivered for it
+CREATE TABLE stats.alert_states (
+    rule_id      uuid PRIMARY KEY REFERENCES stats.alert_rules (id) ON DELETE CASCADE,
+    period_start date             NOT NULL,
+    firing       boolean          NOT NULL,
+    last_value   double precision NOT NULL,
+    triggered_at timestamptz,
+    evaluated_at timestamptz      NOT NULL
+);
@@ -0,0 +1,16 @@
+CREATE TABLE stats.goals (
+    id            uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id       uuid           NOT NULL,
+    name          text           NOT NULL,
+    target_amount numeric(14, 2) NOT NULL CHECK (target_amount > 0),
+    rule          text           NOT NULL CHECK (rule IN ('income', 'net_savings')),
+    category_id   uuid,
+    start_date    date           NOT NULL,
+    deadline      date           NOT NULL,
+    created_at    timestamptz    NOT NULL DEFAULT now(),
+    updated_at    timestamptz    NOT NULL DEFAULT now(),
+    CHECK (rule = 'income' OR category_id IS NULL),
+    CHECK (start_date <= deadline)
+);
+
+CREATE INDEX goals_user_id_idx ON stats.goals (user_id);
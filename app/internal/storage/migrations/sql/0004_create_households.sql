@@ -0,0 +1,18 @@
+CREATE TABLE stats.households (
+    id         uuid PRIMARY KEY DEFAULT gen_random_uuid(),
+    name       text        NOT NULL,
+    created_at timestamptz NOT NULL DEFAULT now(),
+    updated_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE stats.household_members (
+    household_id uuid        NOT NULL REFERENCES stats.households (id) ON DELETE CASCADE,
+    user_id      uuid        NOT NULL,
+    role         text        NOT NULL CHECK (role IN ('owner', 'member')),
+    created_at   timestamptz NOT NULL DEFAULT now(),
+    -- members are invited by an owner and only count once they accept
+    accepted_at  timestamptz,
+    PRIMARY KEY (household_id, user_id)
+);
+
+CREATE INDEX household_members_user_id_idx ON stats.household_members (user_id);
@@ -0,0 +1,279 @@
+package compress
+
+import (
+	"compress/gzip"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	EncodingGzip     = "gzip"
+	EncodingZstd     = "zstd"
+	EncodingIdentity = "identity"
+
+	headerAcceptEncoding  = "Accept-Encoding"
+	headerContentEncoding = "Content-Encoding"
+	headerContentLength   = "Content-Length"
+	headerContentType     = "Content-Type"
+	headerETag            = "ETag"
+	headerVary            = "Vary"
+)
+
+type Options struct {
+	MinSize   int
+	GzipLevel int
+	ZstdLevel int
+}
+
+func (o Options) Validate() error {
+	if o.MinSize < 0 {
+		return fmt.Errorf("compression min size can not be negative: %d", o.MinSize)
+	}
+	if o.GzipLevel < gzip.HuffmanOnly || o.GzipLevel > gzip.BestCompression {
+		return fmt.Errorf("gzip level should be between %d and %d: %d",
+			gzip.HuffmanOnly, gzip.BestCompression, o.GzipLevel)
+	}
+	if o.ZstdLevel < 1 || o.ZstdLevel > 22 {
+		return fmt.Errorf("zstd level should be between 1 and 22: %d", o.ZstdLevel)
+	}
+	return nil
+}
+
+type compressor struct {
+	minSize  int
+	gzipPool sync.Pool
+	zstdPool sync.Pool
+}
+
+// Middleware compresses responses with gzip or zstd depending on the Accept-Encoding
+// request header. Responses smaller than MinSize are sent as is, responses that are
+// flushed before reaching MinSize are treated as streams and always compressed.
+func Middleware(h http.Handler, options Options) http.Handler {
+	c := &compressor{minSize: options.MinSize}
+	c.gzipPool.New = func() interface{} {
+		w, _ := gzip.NewWriterLevel(io.Discard, options.GzipLevel)
+		return w
+	}
+	c.zstdPool.New = func() interface{} {
+		w, _ := zstd.NewWriter(io.Discard,
+			zstd.WithEncoderLevel(zstd.EncoderLevelFromZstd(options.ZstdLevel)),
+			zstd.WithEncoderConcurrency(1))
+		return w
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add(headerVary, headerAcceptEncoding)
+
+		encoding := negotiate(r.Header.Get(headerAcceptEncoding))
+		if encoding == EncodingIdentity || r.Method == http.MethodHead {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &responseWriter{
+			ResponseWriter: w,
+			compressor:     c,
+			encoding:       encoding,
+			status:         http.StatusOK,
+		}
+		defer cw.close()
+
+		h.ServeHTTP(cw, r)
+	})
+}
+
+// negotiate picks the preferred supported encoding from an Accept-Encoding header,
+// preferring zstd over gzip when both have the same quality.
+func negotiate(acceptEncoding string) string {
+	best, bestQuality := EncodingIdentity, 0.0
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, quality := parseCoding(part)
+		if quality <= 0 {
+			continue
+		}
+
+		switch coding {
+		case EncodingZstd:
+			if quality > bestQuality || (quality == bestQuality && best != EncodingZstd) {
+				best, bestQuality = EncodingZstd, quality
+			}
+		case EncodingGzip, "x-gzip":
+			if quality > bestQuality {
+				best, bestQuality = EncodingGzip, quality
+			}
+		case "*":
+			if quality > bestQuality {
+				best, bestQuality = EncodingZstd, quality
+			}
+		}
+	}
+	return best
+}
+
+func parseCoding(part string) (string, float64) {
+	coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+	coding = strings.ToLower(strings.TrimSpace(coding))
+	quality := 1.0
+
+	for _, param := range strings.Split(params, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found || strings.TrimSpace(key) != "q" {
+			continue
+		}
+		q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return coding, 0
+		}
+		quality = q
+	}
+	return coding, quality
+}
+
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+type responseWriter struct {
+	http.ResponseWriter
+	compressor *compressor
+	encoding   string
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	encoder     encoder
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	if code < http.StatusOK {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	w.status = code
+
+	if !bodyAllowed(code) || w.Header().Get(headerContentEncoding) != "" {
+		w.start(false)
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.decided {
+		w.buf = append(w.buf, p...)
+		if len(w.buf) >= w.compressor.minSize {
+			if err := w.start(true); err != nil {
+				return 0, err
+			}
+		}
+		return len(p), nil
+	}
+
+	if w.encoder != nil {
+		return w.encoder.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *responseWriter) Flush() {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if !w.decided {
+		_ = w.start(true)
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// start sends the headers and any buffered data to the client, compressing
+// from now on if requested and still possible.
+func (w *responseWriter) start(compress bool) error {
+	w.decided = true
+	header := w.Header()
+
+	if compress && header.Get(headerContentEncoding) == "" {
+		if header.Get(headerContentType) == "" {
+			header.Set(headerContentType, http.DetectContentType(w.buf))
+		}
+		// a strong ETag promises byte-identical bodies, which no longer holds
+		if tag := header.Get(headerETag); strings.HasPrefix(tag, "\"") {
+			header.Set(headerETag, "W/"+tag)
+		}
+		header.Set(headerContentEncoding, w.encoding)
+		header.Del(headerContentLength)
+		w.encoder = w.compressor.getEncoder(w.encoding, w.ResponseWriter)
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	if len(w.buf) == 0 {
+		return nil
+	}
+	buf := w.buf
+	w.buf = nil
+	if w.encoder != nil {
+		_, err := w.encoder.Write(buf)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(buf)
+	return err
+}
+
+func (w *responseWriter) close() {
+	if !w.decided && w.wroteHeader {
+		_ = w.start(false)
+	}
+	if w.encoder != nil {
+		_ = w.encoder.Close()
+		w.compressor.putEncoder(w.encoding, w.encoder)
+		w.encoder = nil
+	}
+}
+
+func (c *compressor) getEncoder(encoding string, w io.Writer) encoder {
+	switch encoding {
+	case EncodingZstd:
+		zw := c.zstdPool.Get().(*zstd.Encoder)
+		zw.Reset(w)
+		return zw
+	default:
+		gw := c.gzipPool.Get().(*gzip.Writer)
+		gw.Reset(w)
+		return gw
+	}
+}
+
+func (c *compressor) putEncoder(encoding string, e encoder) {
+	switch encoding {
+	case EncodingZstd:
+		c.zstdPool.Put(e)
+	default:
+		c.gzipPool.Put(e)
+	}
+}
+
+func bodyAllowed(status int) bool {
+	return status >= http.StatusOK && status != http.StatusNoContent && status != http.StatusNotModified
+}
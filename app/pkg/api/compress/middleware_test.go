@@ -0,0 +1,176 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{acceptEncoding: "", want: EncodingIdentity},
+		{acceptEncoding: "br", want: EncodingIdentity},
+		{acceptEncoding: "gzip", want: EncodingGzip},
+		{acceptEncoding: "x-gzip", want: EncodingGzip},
+		{acceptEncoding: "gzip, zstd", want: EncodingZstd},
+		{acceptEncoding: "zstd, gzip", want: EncodingZstd},
+		{acceptEncoding: "gzip;q=1.0, zstd;q=0.5", want: EncodingGzip},
+		{acceptEncoding: "GZIP; q=0.8", want: EncodingGzip},
+		{acceptEncoding: "gzip;q=0, zstd;q=0", want: EncodingIdentity},
+		{acceptEncoding: "gzip;q=abc", want: EncodingIdentity},
+		{acceptEncoding: "*", want: EncodingZstd},
+		{acceptEncoding: "gzip, *;q=0.1", want: EncodingGzip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.acceptEncoding, func(t *testing.T) {
+			if got := negotiate(tt.acceptEncoding); got != tt.want {
+				t.Errorf("negotiate(%q) = %s, want %s", tt.acceptEncoding, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	large := bytes.Repeat([]byte(`{"value":1}`), 200)
+	small := []byte(`{"value":1}`)
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		status         int
+		etag           string
+		body           []byte
+		wantEncoding   string
+		wantETag       string
+	}{
+		{name: "gzip", acceptEncoding: "gzip", status: http.StatusOK, etag: `"abc"`, body: large,
+			wantEncoding: EncodingGzip, wantETag: `W/"abc"`},
+		{name: "zstd", acceptEncoding: "zstd", status: http.StatusOK, etag: `"abc"`, body: large,
+			wantEncoding: EncodingZstd, wantETag: `W/"abc"`},
+		{name: "weak tag stays weak", acceptEncoding: "gzip", status: http.StatusOK, etag: `W/"abc"`, body: large,
+			wantEncoding: EncodingGzip, wantETag: `W/"abc"`},
+		{name: "below min size", acceptEncoding: "gzip", status: http.StatusOK, etag: `"abc"`, body: small,
+			wantETag: `"abc"`},
+		{name: "identity", acceptEncoding: "", status: http.StatusOK, etag: `"abc"`, body: large,
+			wantETag: `"abc"`},
+		{name: "not modified", acceptEncoding: "gzip", status: http.StatusNotModified, etag: `"abc"`,
+			wantETag: `"abc"`},
+		{name: "head", method: http.MethodHead, acceptEncoding: "gzip", status: http.StatusOK, etag: `"abc"`,
+			wantETag: `"abc"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set(headerETag, tt.etag)
+				w.WriteHeader(tt.status)
+				_, _ = w.Write(tt.body)
+			}), Options{MinSize: 1024, GzipLevel: gzip.DefaultCompression, ZstdLevel: 3})
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, "/", nil)
+			if tt.acceptEncoding != "" {
+				r.Header.Set(headerAcceptEncoding, tt.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if got := w.Header().Get(headerContentEncoding); got != tt.wantEncoding {
+				t.Errorf("%s = %q, want %q", headerContentEncoding, got, tt.wantEncoding)
+			}
+			if got := w.Header().Get(headerETag); got != tt.wantETag {
+				t.Errorf("%s = %q, want %q", headerETag, got, tt.wantETag)
+			}
+			if got := w.Header().Get(headerVary); got != headerAcceptEncoding {
+				t.Errorf("%s = %q, want %q", headerVary, got, headerAcceptEncoding)
+			}
+			if body := decode(t, tt.wantEncoding, w.Body.Bytes()); !bytes.Equal(body, tt.body) && method != http.MethodHead {
+				t.Errorf("body = %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestMiddlewareFlush(t *testing.T) {
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(headerETag, `"abc"`)
+		_, _ = w.Write([]byte("data: 1\n\n"))
+		w.(http.Flusher).Flush()
+		_, _ = w.Write([]byte("data: 2\n\n"))
+	}), Options{MinSize: 1024, GzipLevel: gzip.DefaultCompression, ZstdLevel: 3})
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(headerAcceptEncoding, "gzip")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	if got := w.Header().Get(headerContentEncoding); got != EncodingGzip {
+		t.Fatalf("%s = %q, want a compressed stream", headerContentEncoding, got)
+	}
+	if got := w.Header().Get(headerETag); got != `W/"abc"` {
+		t.Errorf("%s = %q, want %q", headerETag, got, `W/"abc"`)
+	}
+	if body := decode(t, EncodingGzip, w.Body.Bytes()); string(body) != "data: 1\n\ndata: 2\n\n" {
+		t.Errorf("body = %q", body)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		wantErr bool
+	}{
+		{name: "valid", options: Options{MinSize: 1024, GzipLevel: 6, ZstdLevel: 3}},
+		{name: "negative min size", options: Options{MinSize: -1, GzipLevel: 6, ZstdLevel: 3}, wantErr: true},
+		{name: "gzip level", options: Options{GzipLevel: 10, ZstdLevel: 3}, wantErr: true},
+		{name: "zstd level", options: Options{GzipLevel: 6, ZstdLevel: 0}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.options.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func decode(t *testing.T, encoding string, body []byte) []byte {
+	t.Helper()
+	var reader io.Reader
+	switch encoding {
+	case EncodingGzip:
+		gr, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to read gzip body: %v", err)
+		}
+		reader = gr
+	case EncodingZstd:
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to read zstd body: %v", err)
+		}
+		defer zr.Close()
+		reader = zr
+	default:
+		return body
+	}
+	decoded, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatalf("failed to decode %s body: %v", encoding, err)
+	}
+	return decoded
+}
@@ -0,0 +1,203 @@
+package cors
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	headerOrigin           = "Origin"
+	headerVary             = "Vary"
+	headerRequestMethod    = "Access-Control-Request-Method"
+	headerRequestHeaders   = "Access-Control-Request-Headers"
+	headerAllowOrigin      = "Access-Control-Allow-Origin"
+	headerAllowMethods     = "Access-Control-Allow-Methods"
+	headerAllowHeaders     = "Access-Control-Allow-Headers"
+	headerAllowCredentials = "Access-Control-Allow-Credentials"
+	headerExposeHeaders    = "Access-Control-Expose-Headers"
+	headerMaxAge           = "Access-Control-Max-Age"
+
+	wildcard = "*"
+)
+
+type Options struct {
+	Enabled          bool
+	AllowedOrigins   []string
+	AllowedMethods   []string
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+type originPattern struct {
+	prefix string
+	suffix string
+}
+
+// Policy is a compiled set of CORS options.
+type Policy struct {
+	options        Options
+	anyOrigin      bool
+	anyHeader      bool
+	origins        map[string]struct{}
+	patterns       []originPattern
+	allowedHeaders map[string]struct{}
+	methods        string
+	headers        string
+	exposedHeaders string
+	maxAge         string
+}
+
+// NewPolicy compiles the options. Origins may contain a single * in place of the
+// subdomain, e.g. https://*.example.com matches https://app.example.com but not
+// https://example.com; a plain * allows every origin.
+func NewPolicy(options Options) *Policy {
+	p := &Policy{
+		options:        options,
+		origins:        make(map[string]struct{}),
+		allowedHeaders: make(map[string]struct{}),
+		methods:        strings.Join(options.AllowedMethods, ", "),
+		headers:        strings.Join(options.AllowedHeaders, ", "),
+		exposedHeaders: strings.Join(options.ExposedHeaders, ", "),
+	}
+	if options.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(options.MaxAge.Seconds()))
+	}
+
+	for _, origin := range options.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == wildcard:
+			p.anyOrigin = true
+		case strings.Contains(origin, wildcard):
+			prefix, suffix, _ := strings.Cut(origin, wildcard)
+			p.patterns = append(p.patterns, originPattern{prefix: prefix, suffix: suffix})
+		case origin != "":
+			p.origins[origin] = struct{}{}
+		}
+	}
+
+	for _, header := range options.AllowedHeaders {
+		header = strings.TrimSpace(header)
+		if header == wildcard {
+			p.anyHeader = true
+			continue
+		}
+		p.allowedHeaders[http.CanonicalHeaderKey(header)] = struct{}{}
+	}
+
+	return p
+}
+
+func (p *Policy) originAllowed(origin string) bool {
+	if p.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if _, ok := p.origins[origin]; ok {
+		return true
+	}
+	for _, pattern := range p.patterns {
+		if len(origin) > len(pattern.prefix)+len(pattern.suffix) &&
+			strings.HasPrefix(origin, pattern.prefix) && strings.HasSuffix(origin, pattern.suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Policy) headersAllowed(requested string) bool {
+	if p.anyHeader || requested == "" {
+		return true
+	}
+	for _, header := range strings.Split(requested, ",") {
+		header = strings.TrimSpace(header)
+		if header == "" {
+			continue
+		}
+		if _, ok := p.allowedHeaders[http.CanonicalHeaderKey(header)]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Policy) methodAllowed(method string) bool {
+	for _, allowed := range p.options.AllowedMethods {
+		if strings.EqualFold(allowed, method) {
+			return true
+		}
+	}
+	return false
+}
+
+// Middleware adds CORS headers to responses for allowed origins and answers preflight
+// requests for every route that routeExists reports as registered. policy is called on
+// every request, so the policy can be replaced while the application is running.
+func Middleware(h http.Handler, policy func() *Policy, routeExists func(method, path string) bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := policy()
+		origin := r.Header.Get(headerOrigin)
+		if p == nil || !p.options.Enabled || origin == "" {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add(headerVary, headerOrigin)
+
+		requestMethod := r.Header.Get(headerRequestMethod)
+		isPreflight := r.Method == http.MethodOptions && requestMethod != ""
+		if isPreflight {
+			if !routeExists(requestMethod, r.URL.Path) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			header.Add(headerVary, headerRequestMethod)
+			header.Add(headerVary, headerRequestHeaders)
+
+			requestHeaders := r.Header.Get(headerRequestHeaders)
+			if !p.originAllowed(origin) || !p.methodAllowed(requestMethod) || !p.headersAllowed(requestHeaders) {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			p.setOrigin(header, origin)
+			header.Set(headerAllowMethods, p.methods)
+			if p.anyHeader && requestHeaders != "" {
+				header.Set(headerAllowHeaders, requestHeaders)
+			} else if p.headers != "" {
+				header.Set(headerAllowHeaders, p.headers)
+			}
+			if p.maxAge != "" {
+				header.Set(headerMaxAge, p.maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if p.originAllowed(origin) {
+			p.setOrigin(header, origin)
+			if p.exposedHeaders != "" {
+				header.Set(headerExposeHeaders, p.exposedHeaders)
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+func (p *Policy) setOrigin(header http.Header, origin string) {
+	// the config rejects the wildcard together with credentials, which browsers would not accept
+	if p.anyOrigin {
+		header.Set(headerAllowOrigin, wildcard)
+	} else {
+		header.Set(headerAllowOrigin, origin)
+	}
+	if p.options.AllowCredentials {
+		header.Set(headerAllowCredentials, "true")
+	}
+}
@@ -0,0 +1,48 @@
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	HeaderETag         = "ETag"
+	HeaderIfNoneMatch  = "If-None-Match"
+	HeaderCacheControl = "Cache-Control"
+
+	DefaultCacheControl = "private, no-cache"
+)
+
+// Strong builds a strong entity tag from the given parts. Parts are separated
+// so that ("ab", "c") and ("a", "bc") produce different tags.
+func Strong(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		_, _ = fmt.Fprintf(hash, "%d:%s;", len(part), part)
+	}
+	return fmt.Sprintf("\"%s\"", hex.EncodeToString(hash.Sum(nil)[:16]))
+}
+
+// NoneMatch reports whether the If-None-Match header of the request matches
+// the given tag, which means the client already has the current representation.
+// Weak comparison is used as required by RFC 9110 for If-None-Match.
+func NoneMatch(r *http.Request, tag string) bool {
+	header := r.Header.Get(HeaderIfNoneMatch)
+	if header == "" {
+		return false
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" {
+			return true
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(tag, "W/") {
+			return true
+		}
+	}
+	return false
+}
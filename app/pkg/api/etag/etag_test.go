@@ -0,0 +1,56 @@
+package etag
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestStrong(t *testing.T) {
+	tag := Strong("a", "bc")
+	if !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || strings.HasPrefix(tag, "W/") {
+		t.Fatalf("Strong() = %s, want a quoted strong tag", tag)
+	}
+	if tag != Strong("a", "bc") {
+		t.Errorf("Strong() is not deterministic")
+	}
+	if tag == Strong("ab", "c") {
+		t.Errorf("Strong() does not separate parts")
+	}
+	if tag == Strong("a", "bc", "") {
+		t.Errorf("Strong() ignores an empty part")
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	const tag = `"abc"`
+	tests := []struct {
+		name   string
+		header string
+		tag    string
+		want   bool
+	}{
+		{name: "no header", header: "", tag: tag},
+		{name: "same tag", header: `"abc"`, tag: tag, want: true},
+		{name: "other tag", header: `"abd"`, tag: tag},
+		{name: "unquoted", header: `abc`, tag: tag},
+		{name: "wildcard", header: "*", tag: tag, want: true},
+		{name: "weak header", header: `W/"abc"`, tag: tag, want: true},
+		{name: "weak tag", header: `"abc"`, tag: `W/"abc"`, want: true},
+		{name: "both weak", header: `W/"abc"`, tag: `W/"abc"`, want: true},
+		{name: "list", header: `"x", W/"abc" , "y"`, tag: tag, want: true},
+		{name: "list without match", header: `"x","y"`, tag: tag},
+		{name: "wildcard in list", header: `"x", *`, tag: tag, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				r.Header.Set(HeaderIfNoneMatch, tt.header)
+			}
+			if got := NoneMatch(r, tt.tag); got != tt.want {
+				t.Errorf("NoneMatch(%q, %q) = %v, want %v", tt.header, tt.tag, got, tt.want)
+			}
+		})
+	}
+}
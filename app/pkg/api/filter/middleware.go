@@ -3,6 +3,7 @@ package filter
 import (
 	"context"
 	"net/http"
+	"stats-service/pkg/tracing"
 	"strconv"
 )
 
@@ -10,20 +11,28 @@ const (
 	OptionsContextKey = "filter_options"
 )
 
-func Middleware(h http.HandlerFunc, defaultLimit int) http.HandlerFunc {
+// Middleware parses the limit query parameter. defaultLimit is called on every request,
+// so the default can be changed while the application is running.
+func Middleware(h http.HandlerFunc, defaultLimit func() int) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.StartSpan(r.Context(), "filter.Middleware")
 		limitFromQuery := r.URL.Query().Get("limit")
 
-		limit := defaultLimit
+		limit := defaultLimit()
 		var limitParseErr error
 		if limitFromQuery != "" {
 			if limit, limitParseErr = strconv.Atoi(limitFromQuery); limitParseErr != nil {
+				span.RecordError(limitParseErr)
+				span.Finish()
 				w.WriteHeader(http.StatusBadRequest)
 				_, _ = w.Write([]byte("invalid limit"))
 				return
 			}
 		}
 
+		span.SetAttribute("filter.limit", limit)
+		span.Finish()
+
 		optionsWithLimit := NewOptions(limit)
 		ctx := context.WithValue(r.Context(), OptionsContextKey, optionsWithLimit)
 		r = r.WithContext(ctx)
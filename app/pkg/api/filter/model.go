@@ -2,7 +2,9 @@ package filter
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -63,6 +65,18 @@ func (o *options) AddField(name, operator string, values []string, dataType stri
 	return nil
 }
 
+// CanonicalForm returns a stable textual representation of the options
+// that does not depend on the order in which fields were added.
+func CanonicalForm(options Options) string {
+	fields := make([]string, 0, len(options.Fields()))
+	for _, field := range options.Fields() {
+		fields = append(fields, fmt.Sprintf("%s:%s:%s:%s",
+			field.Name, field.Operator, field.DataType, strings.Join(field.Values, ",")))
+	}
+	sort.Strings(fields)
+	return fmt.Sprintf("limit=%d;%s", options.Limit(), strings.Join(fields, ";"))
+}
+
 func validateField(field Field) error {
 	if err := validateOperator(field.Operator); err != nil {
 		return err
@@ -0,0 +1,52 @@
+package recorder
+
+import "net/http"
+
+// Writer wraps a http.ResponseWriter and records the status code and the size of the response
+// for middlewares that report on served requests.
+type Writer struct {
+	http.ResponseWriter
+	status      int
+	bytes       int
+	wroteHeader bool
+}
+
+func New(w http.ResponseWriter) *Writer {
+	return &Writer{ResponseWriter: w, status: http.StatusOK}
+}
+
+// Status returns the final status code, 200 when the handler did not set one.
+// Informational 1xx responses are not final and are not recorded.
+func (w *Writer) Status() int {
+	return w.status
+}
+
+// Bytes returns the number of body bytes written.
+func (w *Writer) Bytes() int {
+	return w.bytes
+}
+
+func (w *Writer) WriteHeader(code int) {
+	if !w.wroteHeader && code >= http.StatusOK {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *Writer) Write(p []byte) (int, error) {
+	w.wroteHeader = true
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += n
+	return n, err
+}
+
+func (w *Writer) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *Writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
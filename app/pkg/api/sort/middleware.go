@@ -3,6 +3,7 @@ package sort
 import (
 	"context"
 	"net/http"
+	"stats-service/pkg/tracing"
 )
 
 const (
@@ -13,6 +14,7 @@ const (
 
 func Middleware(h http.HandlerFunc, defaultSortField, defaultSortOrder string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		_, span := tracing.StartSpan(r.Context(), "sort.Middleware")
 		sortBy := r.URL.Query().Get("sort_by")
 		sortOrder := r.URL.Query().Get("sort_order")
 
@@ -29,6 +31,10 @@ func Middleware(h http.HandlerFunc, defaultSortField, defaultSortOrder string) h
 			Order: sortOrder,
 		}
 
+		span.SetAttribute("sort.field", sortBy)
+		span.SetAttribute("sort.order", sortOrder)
+		span.Finish()
+
 		ctx := context.WithValue(r.Context(), OptionsContextKey, options)
 		r = r.WithContext(ctx)
 
@@ -6,11 +6,30 @@ import (
 	"io"
 	"os"
 	"path"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
+)
+
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+
+	OutputStdout = "stdout"
+	OutputFile   = "file"
+	OutputBoth   = "both"
+
+	DefaultFilePath = "logs/all.log"
 )
 
 var e *logrus.Entry
 
+var (
+	sinksMu sync.Mutex
+	sinks   []io.Closer
+)
+
 type Logger struct {
 	*logrus.Entry
 }
@@ -23,6 +42,158 @@ func (l *Logger) GetLoggerWithField(key string, value interface{}) *Logger {
 	return &Logger{l.Entry.WithField(key, value)}
 }
 
+type Options struct {
+	Format string
+	Level  string
+	Output string
+	File   FileOptions
+	// ErrorFile receives error, fatal and panic entries in addition to the main sinks.
+	// It is disabled when its path is empty.
+	ErrorFile FileOptions
+}
+
+// Configure applies the format, level and sinks to the logger created by InitLogger.
+// Loggers obtained before the call are affected too, as they share it.
+func Configure(options Options) error {
+	level, err := parseLevel(options.Level)
+	if err != nil {
+		return err
+	}
+
+	var formatter logrus.Formatter
+	switch strings.ToLower(options.Format) {
+	case FormatText, "":
+		formatter = &CustomFormatter{}
+	case FormatJSON:
+		formatter = &logrus.JSONFormatter{
+			TimestampFormat: "2006-01-02T15:04:05.000Z07:00",
+			CallerPrettyfier: func(frame *runtime.Frame) (string, string) {
+				return frame.Function, fmt.Sprintf("%s:%d", path.Base(frame.File), frame.Line)
+			},
+		}
+	default:
+		return fmt.Errorf("invalid log format: %s", options.Format)
+	}
+
+	hooks, closers, err := newHooks(options)
+	if err != nil {
+		return err
+	}
+
+	e.Logger.SetFormatter(formatter)
+	e.Logger.SetLevel(level)
+	e.Logger.ReplaceHooks(hooks)
+
+	sinksMu.Lock()
+	oldSinks := sinks
+	sinks = closers
+	sinksMu.Unlock()
+	closeAll(oldSinks)
+
+	return nil
+}
+
+// SetLevel changes the level of the logger at runtime.
+func SetLevel(level string) error {
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+	e.Logger.SetLevel(parsed)
+	return nil
+}
+
+func ValidateLevel(level string) error {
+	_, err := parseLevel(level)
+	return err
+}
+
+func parseLevel(level string) (logrus.Level, error) {
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return parsed, fmt.Errorf("invalid log level: %w", err)
+	}
+	return parsed, nil
+}
+
+// Close flushes and closes the log files. Entries logged afterwards go to stdout only.
+func Close() {
+	e.Logger.ReplaceHooks(stdoutHooks())
+
+	sinksMu.Lock()
+	oldSinks := sinks
+	sinks = nil
+	sinksMu.Unlock()
+	closeAll(oldSinks)
+}
+
+func newHooks(options Options) (logrus.LevelHooks, []io.Closer, error) {
+	writers := make([]io.Writer, 0, 2)
+	closers := make([]io.Closer, 0, 2)
+
+	switch strings.ToLower(options.Output) {
+	case OutputStdout:
+		writers = append(writers, os.Stdout)
+	case OutputFile, OutputBoth, "":
+		if options.File.Path == "" {
+			options.File.Path = DefaultFilePath
+		}
+		file, err := newRotatingFile(options.File)
+		if err != nil {
+			return nil, nil, err
+		}
+		writers = append(writers, file)
+		closers = append(closers, file)
+		if !strings.EqualFold(options.Output, OutputFile) {
+			writers = append(writers, os.Stdout)
+		}
+	default:
+		return nil, nil, fmt.Errorf("invalid log output: %s", options.Output)
+	}
+
+	hooks := make(logrus.LevelHooks)
+	hooks.Add(&writerHook{
+		Writers:   writers,
+		LogLevels: logrus.AllLevels,
+	})
+
+	if options.ErrorFile.Path != "" {
+		errorFile, err := newRotatingFile(options.ErrorFile)
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, err
+		}
+		closers = append(closers, errorFile)
+		hooks.Add(&writerHook{
+			Writers:   []io.Writer{errorFile},
+			LogLevels: []logrus.Level{logrus.PanicLevel, logrus.FatalLevel, logrus.ErrorLevel},
+		})
+	}
+
+	return hooks, closers, nil
+}
+
+func stdoutHooks() logrus.LevelHooks {
+	return outputHooks(os.Stdout)
+}
+
+func outputHooks(w io.Writer) logrus.LevelHooks {
+	hooks := make(logrus.LevelHooks)
+	hooks.Add(&writerHook{
+		Writers:   []io.Writer{w},
+		LogLevels: logrus.AllLevels,
+	})
+	return hooks
+}
+
+func closeAll(closers []io.Closer) {
+	for _, closer := range closers {
+		if err := closer.Close(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "failed to close log sink: %v\n", err)
+		}
+	}
+}
+
 type writerHook struct {
 	Writers   []io.Writer
 	LogLevels []logrus.Level
@@ -34,7 +205,9 @@ func (hook *writerHook) Fire(entry *logrus.Entry) error {
 		return err
 	}
 	for _, w := range hook.Writers {
-		_, err = w.Write([]byte(line))
+		if _, writeErr := w.Write([]byte(line)); writeErr != nil {
+			err = writeErr
+		}
 	}
 	return err
 }
@@ -50,28 +223,21 @@ func InitLogger() {
 	customFormatter := &CustomFormatter{}
 	l.SetFormatter(customFormatter)
 
-	err := os.MkdirAll("logs", 0755)
-
-	if err != nil || os.IsExist(err) {
-		panic("can't create log dir. logging to file is not configured")
-	} else {
-		logFile, err := os.OpenFile("logs/all.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0660)
-		if err != nil {
-			panic(fmt.Sprintf("[Error]: %s", err))
-		}
-
-		l.SetOutput(io.Discard)
-		l.AddHook(&writerHook{
-			Writers:   []io.Writer{logFile, os.Stdout},
-			LogLevels: logrus.AllLevels,
-		})
-	}
+	// until Configure is called with the application config only stdout is used
+	l.SetOutput(io.Discard)
+	l.ReplaceHooks(stdoutHooks())
 
 	l.SetLevel(logrus.TraceLevel)
 
 	e = logrus.NewEntry(l)
 }
 
+// SetOutput sends the entries of the logger created by InitLogger to w until Configure is called,
+// e.g. to keep stdout free for the output of a command.
+func SetOutput(w io.Writer) {
+	e.Logger.ReplaceHooks(outputHooks(w))
+}
+
 type CustomFormatter struct{}
 
 func (f *CustomFormatter) Format(entry *logrus.Entry) ([]byte, error) {
@@ -85,10 +251,23 @@ func (f *CustomFormatter) Format(entry *logrus.Entry) ([]byte, error) {
 		file = fmt.Sprintf("- %s:%d", path.Base(entry.Caller.File), entry.Caller.Line)
 	}
 
+	fields := ""
+	if len(entry.Data) > 0 {
+		keys := make([]string, 0, len(entry.Data))
+		for key := range entry.Data {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fields += fmt.Sprintf(" %s=%v", key, entry.Data[key])
+		}
+	}
+
 	//formatted := fmt.Sprintf("%s \u001B[%dm%s\u001B[0m %s %s %s \n",
 	//	timestamp, getColorByLevel(entry.Level), level, entry.Message, funcName, file)
-	formatted := fmt.Sprintf("%s %s %s %s %s\n",
-		timestamp, level, entry.Message, funcName, file)
+	formatted := fmt.Sprintf("%s %s %s%s %s %s\n",
+		timestamp, level, entry.Message, fields, funcName, file)
 	return []byte(formatted), nil
 }
 
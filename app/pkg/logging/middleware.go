@@ -0,0 +1,99 @@
+package logging
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"stats-service/pkg/api/recorder"
+	"time"
+)
+
+const (
+	RequestIDHeader     = "X-Request-ID"
+	UserUUIDHeader      = "X-User-UUID"
+	LoggerContextKey    = "logger"
+	RequestIDContextKey = "request_id"
+
+	maxRequestIDLength = 128
+)
+
+// Middleware creates a request scoped logger carrying the request id, user id and route,
+// stores it in the request context and writes an access log line once the request is served.
+// The request id is taken from the X-Request-ID header when present and echoed back; the user id
+// comes from the X-User-UUID header, falling back to the user_uuid query parameter.
+// route returns the pattern of the route serving the request, e.g. /api/budgets/:uuid, so the
+// field stays low-cardinality.
+func Middleware(h http.Handler, logger *Logger, route func(r *http.Request) string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		requestID := r.Header.Get(RequestIDHeader)
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+
+		requestLogger := logger.
+			GetLoggerWithField("request_id", requestID).
+			GetLoggerWithField("method", r.Method).
+			GetLoggerWithField("route", route(r))
+		if userUUID := requestUserUUID(r); userUUID != "" {
+			requestLogger = requestLogger.GetLoggerWithField("user_id", userUUID)
+		}
+
+		ctx := context.WithValue(r.Context(), LoggerContextKey, requestLogger)
+		ctx = context.WithValue(ctx, RequestIDContextKey, requestID)
+		r = r.WithContext(ctx)
+
+		rw := recorder.New(w)
+		h.ServeHTTP(rw, r)
+
+		requestLogger.
+			GetLoggerWithField("status", rw.Status()).
+			GetLoggerWithField("bytes", rw.Bytes()).
+			GetLoggerWithField("latency", time.Since(start).String()).
+			GetLoggerWithField("remote_addr", r.RemoteAddr).
+			Info("request served")
+	})
+}
+
+// LoggerFromContext returns the request scoped logger set by Middleware or fallback if there is none.
+func LoggerFromContext(ctx context.Context, fallback *Logger) *Logger {
+	if logger, ok := ctx.Value(LoggerContextKey).(*Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(RequestIDContextKey).(string)
+	return requestID
+}
+
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range requestID {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestUserUUID returns the caller given in X-User-UUID, or the user_uuid query parameter
+// of requests made without one.
+func requestUserUUID(r *http.Request) string {
+	if userUUID := r.Header.Get(UserUUIDHeader); userUUID != "" {
+		return userUUID
+	}
+	return r.URL.Query().Get("user_uuid")
+}
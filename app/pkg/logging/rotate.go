@@ -0,0 +1,219 @@
+package logging
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	backupTimeFormat = "20060102T150405.000"
+	compressSuffix   = ".gz"
+	megabyte         = 1024 * 1024
+)
+
+type FileOptions struct {
+	Path             string
+	MaxSizeMB        int
+	RotationInterval time.Duration
+	MaxBackups       int
+	Compress         bool
+}
+
+// rotatingFile is a log file that is rotated when it grows over MaxSizeMB or gets older
+// than RotationInterval. Rotated files are renamed with a timestamp suffix, optionally
+// gzipped, and only the MaxBackups newest ones are kept. Zero values disable the limits.
+type rotatingFile struct {
+	options FileOptions
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	wg       sync.WaitGroup
+}
+
+func newRotatingFile(options FileOptions) (*rotatingFile, error) {
+	if options.Path == "" {
+		return nil, fmt.Errorf("log file path is empty")
+	}
+
+	f := &rotatingFile{options: options}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+
+	if f.needsRotation(len(p)) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.wg.Wait()
+	return err
+}
+
+func (f *rotatingFile) needsRotation(writeLen int) bool {
+	if f.size == 0 {
+		return false
+	}
+	if f.options.MaxSizeMB > 0 && f.size+int64(writeLen) > int64(f.options.MaxSizeMB)*megabyte {
+		return true
+	}
+	if f.options.RotationInterval > 0 && time.Since(f.openedAt) >= f.options.RotationInterval {
+		return true
+	}
+	return false
+}
+
+func (f *rotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.options.Path), 0755); err != nil {
+		return fmt.Errorf("can't create log dir: %w", err)
+	}
+
+	file, err := os.OpenFile(f.options.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0660)
+	if err != nil {
+		return fmt.Errorf("can't open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("can't stat log file: %w", err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+	f.openedAt = time.Now()
+	return nil
+}
+
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("can't close log file: %w", err)
+	}
+	f.file = nil
+
+	backup := f.backupName(time.Now())
+	if err := os.Rename(f.options.Path, backup); err != nil {
+		return fmt.Errorf("can't rename log file: %w", err)
+	}
+
+	if err := f.open(); err != nil {
+		return err
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		if f.options.Compress {
+			if err := compressFile(backup); err != nil {
+				_, _ = fmt.Fprintf(os.Stderr, "failed to compress log file %s: %v\n", backup, err)
+			}
+		}
+		if err := f.removeOldBackups(); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "failed to remove old log files: %v\n", err)
+		}
+	}()
+	return nil
+}
+
+func (f *rotatingFile) backupName(t time.Time) string {
+	dir := filepath.Dir(f.options.Path)
+	ext := filepath.Ext(f.options.Path)
+	name := strings.TrimSuffix(filepath.Base(f.options.Path), ext)
+	return filepath.Join(dir, fmt.Sprintf("%s-%s%s", name, t.Format(backupTimeFormat), ext))
+}
+
+func (f *rotatingFile) removeOldBackups() error {
+	if f.options.MaxBackups <= 0 {
+		return nil
+	}
+
+	dir := filepath.Dir(f.options.Path)
+	ext := filepath.Ext(f.options.Path)
+	prefix := strings.TrimSuffix(filepath.Base(f.options.Path), ext) + "-"
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	backups := make([]string, 0)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if strings.HasSuffix(name, ext) || strings.HasSuffix(name, ext+compressSuffix) {
+			backups = append(backups, name)
+		}
+	}
+
+	// the timestamp suffix makes lexical order chronological
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	for i := f.options.MaxBackups; i < len(backups); i++ {
+		if err = os.Remove(filepath.Join(dir, backups[i])); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(name+compressSuffix, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0660)
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(dst)
+	if _, err = io.Copy(gw, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = gw.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err = dst.Close(); err != nil {
+		return err
+	}
+
+	return os.Remove(name)
+}
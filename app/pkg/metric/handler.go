@@ -4,24 +4,34 @@ import (
 	"github.com/julienschmidt/httprouter"
 	"net/http"
 	"stats-service/pkg/logging"
+	"sync/atomic"
 )
 
 const (
-	URL = "/api/heartbeat"
+	URL      = "/api/heartbeat"
+	ReadyURL = "/api/ready"
 )
 
 type Handler struct {
 	Logger *logging.Logger
+	ready  atomic.Bool
 }
 
 func NewHandler(logger *logging.Logger) *Handler {
-	return &Handler{
+	h := &Handler{
 		Logger: logger,
 	}
+	h.ready.Store(true)
+	return h
 }
 
 func (h *Handler) Register(router *httprouter.Router) {
 	router.HandlerFunc(http.MethodGet, URL, h.Heartbeat)
+	router.HandlerFunc(http.MethodGet, ReadyURL, h.Ready)
+}
+
+func (h *Handler) SetReady(ready bool) {
+	h.ready.Store(ready)
 }
 
 // Heartbeat
@@ -33,3 +43,18 @@ func (h *Handler) Register(router *httprouter.Router) {
 func (h *Handler) Heartbeat(w http.ResponseWriter, req *http.Request) {
 	w.WriteHeader(204)
 }
+
+// Ready
+// @Summary 	Readiness
+// @Description Checks that the server accepts traffic, fails while it is shutting down
+// @Tags 		Heartbeat
+// @Success 	204
+// @Failure 	503
+// @Router 		/ready [get]
+func (h *Handler) Ready(w http.ResponseWriter, req *http.Request) {
+	if !h.ready.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -7,13 +7,13 @@ import (
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/sirupsen/logrus"
+	"net"
+	"net/url"
 	"stats-service/internal/config"
 	"stats-service/pkg/utils"
-	"time"
+	"strconv"
 )
 
-const waitTime = 5 * time.Second
-
 type Client interface {
 	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
 	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
@@ -22,28 +22,24 @@ type Client interface {
 }
 
 func NewClient(ctx context.Context, connectionAttempts int, cfg config.Config) (*pgxpool.Pool, error) {
-	var dsn string
-	if cfg.Postgres.Username == "" && cfg.Postgres.Password == "" {
-		dsn = fmt.Sprintf("postgresql://%s:%s/%s",
-			cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.Database)
-	} else {
-		dsn = fmt.Sprintf("postgresql://%s:%s@%s:%s/%s",
-			cfg.Postgres.Username, cfg.Postgres.Password, cfg.Postgres.Host, cfg.Postgres.Port, cfg.Postgres.Database)
+	poolConfig, err := NewPoolConfig(cfg, cfg.Postgres.Host, cfg.Postgres.Port)
+	if err != nil {
+		return nil, err
 	}
 
 	var pool *pgxpool.Pool
-	err := utils.DoWithAttempts(func() error {
-		ctx, cancel := context.WithTimeout(ctx, waitTime)
+	err = utils.DoWithAttempts(func() error {
+		ctx, cancel := context.WithTimeout(ctx, cfg.Postgres.ConnectTimeout)
 		defer cancel()
 
 		var pgxErr error
-		pool, pgxErr = pgxpool.Connect(ctx, dsn)
+		pool, pgxErr = pgxpool.ConnectConfig(ctx, poolConfig)
 		if pgxErr != nil {
 			return pgxErr
 		}
 
 		return nil
-	}, connectionAttempts, waitTime)
+	}, connectionAttempts, cfg.Postgres.RetryDelay)
 
 	if err != nil {
 		logrus.Fatal("Error connecting to database: ", err)
@@ -58,3 +54,56 @@ func NewClient(ctx context.Context, connectionAttempts int, cfg config.Config) (
 
 	return pool, nil
 }
+
+// NewPoolConfig builds the pool configuration for the given host from the postgres settings.
+// The connection string is assembled with net/url so credentials with special characters
+// are escaped properly.
+func NewPoolConfig(cfg config.Config, host, port string) (*pgxpool.Config, error) {
+	dsn := url.URL{
+		Scheme: "postgresql",
+		Host:   net.JoinHostPort(host, port),
+		Path:   "/" + cfg.Postgres.Database,
+	}
+	if cfg.Postgres.Username != "" || cfg.Postgres.Password != "" {
+		dsn.User = url.UserPassword(cfg.Postgres.Username, cfg.Postgres.Password)
+	}
+
+	params := url.Values{}
+	if cfg.Postgres.SSLMode != "" {
+		params.Set("sslmode", cfg.Postgres.SSLMode)
+	}
+	if cfg.Postgres.ApplicationName != "" {
+		params.Set("application_name", cfg.Postgres.ApplicationName)
+	}
+	if cfg.Postgres.StatementTimeout > 0 {
+		params.Set("statement_timeout", strconv.FormatInt(cfg.Postgres.StatementTimeout.Milliseconds(), 10))
+	}
+	dsn.RawQuery = params.Encode()
+
+	poolConfig, err := pgxpool.ParseConfig(dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid postgres configuration: %w", err)
+	}
+
+	if cfg.Postgres.MaxConns > 0 {
+		poolConfig.MaxConns = cfg.Postgres.MaxConns
+	}
+	if cfg.Postgres.MinConns > 0 {
+		poolConfig.MinConns = cfg.Postgres.MinConns
+	}
+	if poolConfig.MinConns > poolConfig.MaxConns {
+		return nil, fmt.Errorf("postgres min conns %d is greater than max conns %d",
+			poolConfig.MinConns, poolConfig.MaxConns)
+	}
+	if cfg.Postgres.MaxConnLifetime > 0 {
+		poolConfig.MaxConnLifetime = cfg.Postgres.MaxConnLifetime
+	}
+	if cfg.Postgres.MaxConnIdleTime > 0 {
+		poolConfig.MaxConnIdleTime = cfg.Postgres.MaxConnIdleTime
+	}
+	if cfg.Postgres.ConnectTimeout > 0 {
+		poolConfig.ConnConfig.ConnectTimeout = cfg.Postgres.ConnectTimeout
+	}
+
+	return poolConfig, nil
+}
@@ -0,0 +1,223 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"io"
+	"net"
+	"stats-service/internal/config"
+	"stats-service/pkg/logging"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// replicaPool is the part of *pgxpool.Pool used for a replica.
+type replicaPool interface {
+	Client
+	Ping(ctx context.Context) error
+	Close()
+}
+
+type replica struct {
+	name    string
+	pool    replicaPool
+	healthy atomic.Bool
+}
+
+// ReplicaRouter spreads read queries over the configured read replicas in round-robin order.
+// Replicas are pinged periodically and skipped while unhealthy; when none is available,
+// or a replica fails with a connection error, the query goes to the primary.
+type ReplicaRouter struct {
+	primary  Client
+	replicas []*replica
+	next     atomic.Uint64
+	logger   *logging.Logger
+
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewReplicaRouter(primary Client, cfg config.Config, logger *logging.Logger) (*ReplicaRouter, error) {
+	router := &ReplicaRouter{
+		primary: primary,
+		logger:  logger,
+		stop:    make(chan struct{}),
+	}
+
+	for _, replicaCfg := range cfg.Postgres.Replicas {
+		poolConfig, err := NewPoolConfig(cfg, replicaCfg.Host, replicaCfg.Port)
+		if err != nil {
+			router.Close()
+			return nil, err
+		}
+		// replicas must not delay startup, their state is found by the health check
+		poolConfig.LazyConnect = true
+
+		pool, err := pgxpool.ConnectConfig(context.Background(), poolConfig)
+		if err != nil {
+			router.Close()
+			return nil, fmt.Errorf("failed to create replica pool: %w", err)
+		}
+		router.replicas = append(router.replicas, &replica{
+			name: net.JoinHostPort(replicaCfg.Host, replicaCfg.Port),
+			pool: pool,
+		})
+	}
+
+	if len(router.replicas) > 0 {
+		router.checkHealth(cfg.Postgres.ConnectTimeout)
+		router.wg.Add(1)
+		go router.runHealthCheck(cfg.Postgres.ReplicaCheckInterval, cfg.Postgres.ConnectTimeout)
+	}
+
+	return router, nil
+}
+
+// Reader returns a client for read-only queries.
+func (r *ReplicaRouter) Reader() Client {
+	return &readClient{router: r}
+}
+
+func (r *ReplicaRouter) Close() error {
+	select {
+	case <-r.stop:
+	default:
+		close(r.stop)
+	}
+	r.wg.Wait()
+
+	for _, rep := range r.replicas {
+		rep.pool.Close()
+	}
+	return nil
+}
+
+func (r *ReplicaRouter) runHealthCheck(interval, timeout time.Duration) {
+	defer r.wg.Done()
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			r.checkHealth(timeout)
+		}
+	}
+}
+
+func (r *ReplicaRouter) checkHealth(timeout time.Duration) {
+	for _, rep := range r.replicas {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		err := rep.pool.Ping(ctx)
+		cancel()
+
+		healthy := err == nil
+		if rep.healthy.Swap(healthy) != healthy {
+			if healthy {
+				r.logger.Infof("read replica %s is healthy", rep.name)
+			} else {
+				r.logger.Warnf("read replica %s is unhealthy: %v", rep.name, err)
+			}
+		}
+	}
+}
+
+func (r *ReplicaRouter) pick() (*replica, Client) {
+	count := uint64(len(r.replicas))
+	for i := uint64(0); i < count; i++ {
+		rep := r.replicas[(r.next.Add(1)-1)%count]
+		if rep.healthy.Load() {
+			return rep, rep.pool
+		}
+	}
+	return nil, r.primary
+}
+
+func (r *ReplicaRouter) markFailed(rep *replica, err error) {
+	if rep.healthy.Swap(false) {
+		r.logger.Warnf("read replica %s failed, falling back to primary: %v", rep.name, err)
+	}
+}
+
+// isConnectionError tells errors of the replica itself apart from errors of the query,
+// which would fail on the primary as well. Only failures to reach or keep talking to the
+// server count; server-side errors and Scan or conversion errors never do.
+func isConnectionError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return false
+	}
+	if pgconn.SafeToRetry(err) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+type readClient struct {
+	router *ReplicaRouter
+}
+
+func (c *readClient) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	rep, client := c.router.pick()
+	tag, err := client.Exec(ctx, sql, arguments...)
+	if rep != nil && isConnectionError(err) {
+		c.router.markFailed(rep, err)
+		return c.router.primary.Exec(ctx, sql, arguments...)
+	}
+	return tag, err
+}
+
+func (c *readClient) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rep, client := c.router.pick()
+	rows, err := client.Query(ctx, sql, args...)
+	if rep != nil && isConnectionError(err) {
+		c.router.markFailed(rep, err)
+		return c.router.primary.Query(ctx, sql, args...)
+	}
+	return rows, err
+}
+
+func (c *readClient) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	rep, client := c.router.pick()
+	if rep == nil {
+		return client.QueryRow(ctx, sql, args...)
+	}
+	return fallbackRow(func(dest ...interface{}) error {
+		err := client.QueryRow(ctx, sql, args...).Scan(dest...)
+		if isConnectionError(err) {
+			c.router.markFailed(rep, err)
+			return c.router.primary.QueryRow(ctx, sql, args...).Scan(dest...)
+		}
+		return err
+	})
+}
+
+func (c *readClient) Begin(ctx context.Context) (pgx.Tx, error) {
+	rep, client := c.router.pick()
+	tx, err := client.Begin(ctx)
+	if rep != nil && isConnectionError(err) {
+		c.router.markFailed(rep, err)
+		return c.router.primary.Begin(ctx)
+	}
+	return tx, err
+}
+
+type fallbackRow func(dest ...interface{}) error
+
+func (r fallbackRow) Scan(dest ...interface{}) error {
+	return r(dest...)
+}
@@ -0,0 +1,207 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"io"
+	"net"
+	"reflect"
+	"stats-service/pkg/logging"
+	"testing"
+	"time"
+)
+
+// fakePool records the queries it receives in calls and fails them with err.
+type fakePool struct {
+	name    string
+	err     error
+	pingErr error
+	calls   *[]string
+}
+
+func (p *fakePool) record() {
+	*p.calls = append(*p.calls, p.name)
+}
+
+func (p *fakePool) Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error) {
+	p.record()
+	return nil, p.err
+}
+
+func (p *fakePool) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	p.record()
+	return nil, p.err
+}
+
+func (p *fakePool) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	p.record()
+	return fallbackRow(func(dest ...interface{}) error { return p.err })
+}
+
+func (p *fakePool) Begin(ctx context.Context) (pgx.Tx, error) {
+	p.record()
+	return nil, p.err
+}
+
+func (p *fakePool) Ping(ctx context.Context) error {
+	return p.pingErr
+}
+
+func (p *fakePool) Close() {}
+
+func newTestRouter(calls *[]string, replicas ...*fakePool) *ReplicaRouter {
+	logging.InitLogger()
+	logging.SetOutput(io.Discard)
+	router := &ReplicaRouter{
+		primary: &fakePool{name: "primary", calls: calls},
+		logger:  logging.GetLogger(),
+		stop:    make(chan struct{}),
+	}
+	for _, pool := range replicas {
+		pool.calls = calls
+		rep := &replica{name: pool.name, pool: pool}
+		rep.healthy.Store(true)
+		router.replicas = append(router.replicas, rep)
+	}
+	return router
+}
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil},
+		{name: "cancelled", err: context.Canceled},
+		{name: "deadline", err: fmt.Errorf("query: %w", context.DeadlineExceeded)},
+		{name: "server error", err: &pgconn.PgError{Code: "42P01", Message: "relation does not exist"}},
+		{name: "no rows", err: pgx.ErrNoRows},
+		{name: "scan error", err: errors.New("can't scan into dest[0]: cannot assign")},
+		{name: "eof", err: io.EOF, want: true},
+		{name: "unexpected eof", err: fmt.Errorf("read: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "network error", err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectionError(tt.err); got != tt.want {
+				t.Errorf("isConnectionError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReaderRoundRobin(t *testing.T) {
+	var calls []string
+	router := newTestRouter(&calls, &fakePool{name: "a"}, &fakePool{name: "b"})
+	reader := router.Reader()
+
+	for i := 0; i < 4; i++ {
+		_, _ = reader.Exec(context.Background(), "SELECT 1")
+	}
+	if want := []string{"a", "b", "a", "b"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls = %v, want %v", calls, want)
+	}
+
+	calls = nil
+	router.replicas[0].healthy.Store(false)
+	for i := 0; i < 3; i++ {
+		_, _ = reader.Exec(context.Background(), "SELECT 1")
+	}
+	if want := []string{"b", "b", "b"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls with an unhealthy replica = %v, want %v", calls, want)
+	}
+
+	calls = nil
+	router.replicas[1].healthy.Store(false)
+	_, _ = reader.Exec(context.Background(), "SELECT 1")
+	if want := []string{"primary"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("calls without healthy replicas = %v, want %v", calls, want)
+	}
+}
+
+func TestReaderFallback(t *testing.T) {
+	queryErr := &pgconn.PgError{Code: "42703", Message: "column does not exist"}
+	tests := []struct {
+		name        string
+		err         error
+		query       func(reader Client) error
+		wantCalls   []string
+		wantErr     error
+		wantHealthy bool
+	}{
+		{name: "query connection error", err: io.ErrUnexpectedEOF,
+			query: func(reader Client) error {
+				_, err := reader.Query(context.Background(), "SELECT 1")
+				return err
+			},
+			wantCalls: []string{"replica", "primary"}},
+		{name: "query error", err: queryErr,
+			query: func(reader Client) error {
+				_, err := reader.Query(context.Background(), "SELECT 1")
+				return err
+			},
+			wantCalls: []string{"replica"}, wantErr: queryErr, wantHealthy: true},
+		{name: "query row connection error", err: io.EOF,
+			query: func(reader Client) error {
+				var value int
+				return reader.QueryRow(context.Background(), "SELECT 1").Scan(&value)
+			},
+			wantCalls: []string{"replica", "primary"}},
+		{name: "query row without rows", err: pgx.ErrNoRows,
+			query: func(reader Client) error {
+				var value int
+				return reader.QueryRow(context.Background(), "SELECT 1").Scan(&value)
+			},
+			wantCalls: []string{"replica"}, wantErr: pgx.ErrNoRows, wantHealthy: true},
+		{name: "exec connection error", err: &net.OpError{Op: "read", Net: "tcp", Err: errors.New("reset")},
+			query: func(reader Client) error {
+				_, err := reader.Exec(context.Background(), "SELECT 1")
+				return err
+			},
+			wantCalls: []string{"replica", "primary"}},
+		{name: "begin connection error", err: io.EOF,
+			query: func(reader Client) error {
+				_, err := reader.Begin(context.Background())
+				return err
+			},
+			wantCalls: []string{"replica", "primary"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls []string
+			router := newTestRouter(&calls, &fakePool{name: "replica", err: tt.err})
+
+			if err := tt.query(router.Reader()); !errors.Is(err, tt.wantErr) {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(calls, tt.wantCalls) {
+				t.Errorf("calls = %v, want %v", calls, tt.wantCalls)
+			}
+			if healthy := router.replicas[0].healthy.Load(); healthy != tt.wantHealthy {
+				t.Errorf("replica healthy = %v, want %v", healthy, tt.wantHealthy)
+			}
+		})
+	}
+}
+
+func TestCheckHealth(t *testing.T) {
+	var calls []string
+	down := &fakePool{name: "down", pingErr: errors.New("connection refused")}
+	router := newTestRouter(&calls, &fakePool{name: "up"}, down)
+
+	router.checkHealth(time.Second)
+	if !router.replicas[0].healthy.Load() || router.replicas[1].healthy.Load() {
+		t.Fatalf("healthy = %v, %v, want true, false",
+			router.replicas[0].healthy.Load(), router.replicas[1].healthy.Load())
+	}
+
+	down.pingErr = nil
+	router.checkHealth(time.Second)
+	if !router.replicas[1].healthy.Load() {
+		t.Errorf("recovered replica is still unhealthy")
+	}
+}
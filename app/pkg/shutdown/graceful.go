@@ -1,23 +1,99 @@
 package shutdown
 
 import (
-	"io"
+	"context"
 	"os"
 	"os/signal"
+	"sort"
 	"stats-service/pkg/logging"
+	"sync"
+	"time"
 )
 
-func Graceful(signals []os.Signal, closeItems ...io.Closer) {
-	logger := logging.GetLogger()
+type Phase int
 
+// Hooks run phase by phase in this order, and in registration order within a phase.
+const (
+	// PhaseReadiness stops advertising the service as ready so no new traffic is routed to it.
+	PhaseReadiness Phase = iota
+	// PhaseServer stops accepting requests and drains the in-flight ones.
+	PhaseServer
+	// PhaseWorkers stops background jobs that may still use storage.
+	PhaseWorkers
+	// PhaseStorage closes database pools and exporters.
+	PhaseStorage
+	// PhaseLogging flushes and closes log sinks, nothing is logged to files afterwards.
+	PhaseLogging
+)
+
+type Hook func(ctx context.Context) error
+
+type hook struct {
+	phase Phase
+	name  string
+	fn    Hook
+}
+
+// Manager runs the registered shutdown hooks once a signal is received.
+// All hooks share a single deadline of timeout counted from the signal.
+type Manager struct {
+	timeout time.Duration
+	logger  *logging.Logger
+
+	mu    sync.Mutex
+	hooks []hook
+	done  chan struct{}
+}
+
+func NewManager(timeout time.Duration, logger *logging.Logger) *Manager {
+	return &Manager{
+		timeout: timeout,
+		logger:  logger,
+		done:    make(chan struct{}),
+	}
+}
+
+func (m *Manager) Register(phase Phase, name string, fn Hook) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.hooks = append(m.hooks, hook{phase: phase, name: name, fn: fn})
+}
+
+// Graceful blocks until one of the signals is caught and then runs the hooks.
+func (m *Manager) Graceful(signals []os.Signal) {
 	sigChannel := make(chan os.Signal, 1)
 	signal.Notify(sigChannel, signals...)
 	sig := <-sigChannel
-	logger.Infof("Caught signal %s. Shutting down...", sig)
+	m.logger.Infof("Caught signal %s. Shutting down...", sig)
+
+	m.Shutdown()
+}
+
+// Shutdown runs the hooks and closes the channel returned by Done.
+func (m *Manager) Shutdown() {
+	m.mu.Lock()
+	hooks := make([]hook, len(m.hooks))
+	copy(hooks, m.hooks)
+	m.mu.Unlock()
 
-	for _, closer := range closeItems {
-		if err := closer.Close(); err != nil {
-			logger.Errorf("failed to close %v: %v", closer, err)
+	sort.SliceStable(hooks, func(i, j int) bool {
+		return hooks[i].phase < hooks[j].phase
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	for _, h := range hooks {
+		m.logger.Infof("shutdown: %s", h.name)
+		if err := h.fn(ctx); err != nil {
+			m.logger.Errorf("shutdown: %s failed: %v", h.name, err)
 		}
 	}
+
+	close(m.done)
+}
+
+// Done is closed after all hooks have run.
+func (m *Manager) Done() <-chan struct{} {
+	return m.done
 }
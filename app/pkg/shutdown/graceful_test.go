@@ -0,0 +1,113 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"reflect"
+	"stats-service/pkg/logging"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func newTestManager(timeout time.Duration) *Manager {
+	logging.InitLogger()
+	logging.SetOutput(io.Discard)
+	return NewManager(timeout, logging.GetLogger())
+}
+
+func TestShutdownOrder(t *testing.T) {
+	m := newTestManager(time.Second)
+	var order []string
+	register := func(phase Phase, name string, err error) {
+		m.Register(phase, name, func(ctx context.Context) error {
+			order = append(order, name)
+			return err
+		})
+	}
+	register(PhaseLogging, "logs", nil)
+	register(PhaseStorage, "database", nil)
+	register(PhaseServer, "http server", errors.New("drain failed"))
+	register(PhaseWorkers, "alerts", nil)
+	register(PhaseReadiness, "readiness", nil)
+	register(PhaseStorage, "tracing", nil)
+
+	m.Shutdown()
+
+	want := []string{"readiness", "http server", "alerts", "database", "tracing", "logs"}
+	if !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	select {
+	case <-m.Done():
+	default:
+		t.Errorf("Done() is not closed after Shutdown")
+	}
+}
+
+func TestShutdownSharedDeadline(t *testing.T) {
+	const timeout = 50 * time.Millisecond
+	m := newTestManager(timeout)
+	start := time.Now()
+
+	var deadlines []time.Time
+	var lateErr error
+	m.Register(PhaseServer, "slow", func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		deadlines = append(deadlines, deadline)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	m.Register(PhaseStorage, "late", func(ctx context.Context) error {
+		deadline, _ := ctx.Deadline()
+		deadlines = append(deadlines, deadline)
+		lateErr = ctx.Err()
+		return nil
+	})
+
+	m.Shutdown()
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown took %s, want about %s", elapsed, timeout)
+	}
+	if len(deadlines) != 2 || !deadlines[0].Equal(deadlines[1]) {
+		t.Errorf("deadlines = %v, want one deadline for all hooks", deadlines)
+	}
+	if !errors.Is(lateErr, context.DeadlineExceeded) {
+		t.Errorf("hook after the deadline got ctx error %v, want %v", lateErr, context.DeadlineExceeded)
+	}
+}
+
+func TestGraceful(t *testing.T) {
+	m := newTestManager(time.Second)
+	called := make(chan struct{})
+	m.Register(PhaseServer, "http server", func(ctx context.Context) error {
+		close(called)
+		return nil
+	})
+
+	// subscribing first keeps a signal sent before Graceful subscribes from killing the test
+	early := make(chan os.Signal, 1)
+	signal.Notify(early, syscall.SIGUSR1)
+	defer signal.Stop(early)
+
+	go m.Graceful([]os.Signal{syscall.SIGUSR1})
+	// keep sending until Graceful has subscribed and a hook runs
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case <-called:
+			<-m.Done()
+			return
+		case <-ticker.C:
+			_ = syscall.Kill(os.Getpid(), syscall.SIGUSR1)
+		case <-timeout:
+			t.Fatal("Graceful did not run the hooks after the signal")
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchSize     = 512
+	defaultFlushInterval = 5 * time.Second
+	exportTimeout        = 10 * time.Second
+	queueFactor          = 4
+
+	statusCodeError = 2
+	scopeName       = "stats-service/pkg/tracing"
+)
+
+type exporter interface {
+	export(ctx context.Context, spans []*Span) error
+	close() error
+}
+
+// batchProcessor collects finished spans and hands them to the exporter in batches,
+// either when a batch is full or when the flush interval elapses. Spans are dropped
+// when the queue is full so that tracing never blocks request handling.
+type batchProcessor struct {
+	exporter      exporter
+	batchSize     int
+	flushInterval time.Duration
+
+	queue chan *Span
+	done  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+func newBatchProcessor(exporter exporter, batchSize int, flushInterval time.Duration) *batchProcessor {
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	p := &batchProcessor{
+		exporter:      exporter,
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		queue:         make(chan *Span, batchSize*queueFactor),
+		done:          make(chan struct{}),
+	}
+	p.wg.Add(1)
+	go p.run()
+	return p
+}
+
+func (p *batchProcessor) onEnd(span *Span) {
+	select {
+	case <-p.done:
+	case p.queue <- span:
+	default:
+	}
+}
+
+func (p *batchProcessor) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, p.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		if err := p.exporter.export(ctx, batch); err != nil {
+			_, _ = fmt.Fprintf(os.Stderr, "failed to export %d spans: %v\n", len(batch), err)
+		}
+		cancel()
+		batch = make([]*Span, 0, p.batchSize)
+	}
+
+	for {
+		select {
+		case span := <-p.queue:
+			batch = append(batch, span)
+			if len(batch) >= p.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-p.done:
+			for {
+				select {
+				case span := <-p.queue:
+					batch = append(batch, span)
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+func (p *batchProcessor) close() error {
+	var err error
+	p.once.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+		err = p.exporter.close()
+	})
+	return err
+}
+
+type otlpExporter struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+}
+
+func newOTLPExporter(endpoint, serviceName string) (*otlpExporter, error) {
+	if endpoint == "" {
+		return nil, fmt.Errorf("tracing endpoint is empty")
+	}
+	return &otlpExporter{
+		endpoint:    endpoint,
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: exportTimeout},
+	}, nil
+}
+
+func (e *otlpExporter) export(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(newExportRequest(e.serviceName, spans))
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send spans: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("collector responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func (e *otlpExporter) close() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// fileExporter appends every batch as one line of OTLP JSON, the same payload that
+// is sent to a collector, so the file can later be replayed or inspected offline.
+type fileExporter struct {
+	serviceName string
+	mu          sync.Mutex
+	file        *os.File
+}
+
+func newFileExporter(path, serviceName string) (*fileExporter, error) {
+	if path == "" {
+		return nil, fmt.Errorf("tracing file path is empty")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("can't create tracing dir: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0660)
+	if err != nil {
+		return nil, fmt.Errorf("can't open tracing file: %w", err)
+	}
+	return &fileExporter{
+		serviceName: serviceName,
+		file:        file,
+	}, nil
+}
+
+func (e *fileExporter) export(_ context.Context, spans []*Span) error {
+	body, err := json.Marshal(newExportRequest(e.serviceName, spans))
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err = e.file.Write(append(body, '\n'))
+	return err
+}
+
+func (e *fileExporter) close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.file.Close()
+}
+
+type exportRequest struct {
+	ResourceSpans []resourceSpans `json:"resourceSpans"`
+}
+
+type resourceSpans struct {
+	Resource   resource     `json:"resource"`
+	ScopeSpans []scopeSpans `json:"scopeSpans"`
+}
+
+type resource struct {
+	Attributes []keyValue `json:"attributes"`
+}
+
+type scopeSpans struct {
+	Scope scope      `json:"scope"`
+	Spans []spanData `json:"spans"`
+}
+
+type scope struct {
+	Name string `json:"name"`
+}
+
+type spanData struct {
+	TraceID           string     `json:"traceId"`
+	SpanID            string     `json:"spanId"`
+	ParentSpanID      string     `json:"parentSpanId,omitempty"`
+	Name              string     `json:"name"`
+	Kind              SpanKind   `json:"kind"`
+	StartTimeUnixNano string     `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string     `json:"endTimeUnixNano"`
+	Attributes        []keyValue `json:"attributes,omitempty"`
+	Status            *status    `json:"status,omitempty"`
+}
+
+type status struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type keyValue struct {
+	Key   string   `json:"key"`
+	Value anyValue `json:"value"`
+}
+
+type anyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+func newExportRequest(serviceName string, spans []*Span) exportRequest {
+	data := make([]spanData, 0, len(spans))
+	for _, span := range spans {
+		data = append(data, newSpanData(span))
+	}
+
+	return exportRequest{
+		ResourceSpans: []resourceSpans{{
+			Resource: resource{
+				Attributes: []keyValue{newKeyValue("service.name", serviceName)},
+			},
+			ScopeSpans: []scopeSpans{{
+				Scope: scope{Name: scopeName},
+				Spans: data,
+			}},
+		}},
+	}
+}
+
+func newSpanData(span *Span) spanData {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+
+	data := spanData{
+		TraceID:           span.Context.TraceID.String(),
+		SpanID:            span.Context.SpanID.String(),
+		Name:              span.Name,
+		Kind:              span.Kind,
+		StartTimeUnixNano: strconv.FormatInt(span.Start.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.End.UnixNano(), 10),
+	}
+	if span.ParentID != (SpanID{}) {
+		data.ParentSpanID = span.ParentID.String()
+	}
+	for key, value := range span.Attributes {
+		data.Attributes = append(data.Attributes, newKeyValue(key, value))
+	}
+	if span.Err != nil {
+		data.Status = &status{Code: statusCodeError, Message: span.Err.Error()}
+	}
+	return data
+}
+
+func newKeyValue(key string, value interface{}) keyValue {
+	var v anyValue
+	switch val := value.(type) {
+	case string:
+		v.StringValue = &val
+	case bool:
+		v.BoolValue = &val
+	case int:
+		s := strconv.Itoa(val)
+		v.IntValue = &s
+	case int64:
+		s := strconv.FormatInt(val, 10)
+		v.IntValue = &s
+	case float64:
+		v.DoubleValue = &val
+	default:
+		s := fmt.Sprint(val)
+		v.StringValue = &s
+	}
+	return keyValue{Key: key, Value: v}
+}
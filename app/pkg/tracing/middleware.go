@@ -0,0 +1,41 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"stats-service/pkg/api/recorder"
+	"stats-service/pkg/logging"
+)
+
+// Middleware starts a server span for every request, continuing the trace from the
+// traceparent header if the caller sent one, and adds the trace id to the request logger.
+func Middleware(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if global == nil {
+			h.ServeHTTP(w, r)
+			return
+		}
+
+		remote, _ := ParseTraceParent(r.Header.Get(TraceParentHeader))
+		ctx, span := startSpan(r.Context(), fmt.Sprintf("%s %s", r.Method, r.URL.Path), KindServer, remote)
+		defer span.Finish()
+
+		span.SetAttribute("http.request.method", r.Method)
+		span.SetAttribute("url.path", r.URL.Path)
+		span.SetAttribute("user_agent.original", r.UserAgent())
+
+		if logger := logging.LoggerFromContext(ctx, nil); logger != nil {
+			logger = logger.GetLoggerWithField("trace_id", span.Context.TraceID.String())
+			ctx = context.WithValue(ctx, logging.LoggerContextKey, logger)
+		}
+
+		rw := recorder.New(w)
+		h.ServeHTTP(rw, r.WithContext(ctx))
+
+		span.SetAttribute("http.response.status_code", rw.Status())
+		if rw.Status() >= http.StatusInternalServerError || rw.Status() == http.StatusTeapot {
+			span.RecordError(fmt.Errorf("%s", http.StatusText(rw.Status())))
+		}
+	})
+}
@@ -0,0 +1,69 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	TraceParentHeader = "traceparent"
+	TraceStateHeader  = "tracestate"
+
+	traceVersion = "00"
+	flagSampled  = 0x01
+)
+
+// ParseTraceParent parses a W3C trace context traceparent header value.
+func ParseTraceParent(value string) (SpanContext, error) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return sc, fmt.Errorf("invalid traceparent: %s", value)
+	}
+
+	version := parts[0]
+	if len(version) != 2 || version == "ff" || (version == traceVersion && len(parts) != 4) {
+		return sc, fmt.Errorf("unsupported traceparent version: %s", version)
+	}
+
+	traceID, err := hex.DecodeString(parts[1])
+	if err != nil || len(traceID) != len(sc.TraceID) {
+		return sc, fmt.Errorf("invalid trace id: %s", parts[1])
+	}
+	spanID, err := hex.DecodeString(parts[2])
+	if err != nil || len(spanID) != len(sc.SpanID) {
+		return sc, fmt.Errorf("invalid parent id: %s", parts[2])
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil || len(flags) != 1 {
+		return sc, fmt.Errorf("invalid trace flags: %s", parts[3])
+	}
+
+	copy(sc.TraceID[:], traceID)
+	copy(sc.SpanID[:], spanID)
+	sc.Sampled = flags[0]&flagSampled != 0
+	if !sc.IsValid() {
+		return sc, fmt.Errorf("invalid traceparent: %s", value)
+	}
+	return sc, nil
+}
+
+func FormatTraceParent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("%s-%s-%s-%s", traceVersion, sc.TraceID, sc.SpanID, flags)
+}
+
+// Inject writes the traceparent of the span stored in ctx into the outgoing request headers.
+func Inject(ctx context.Context, header http.Header) {
+	span := SpanFromContext(ctx)
+	if span == nil {
+		return
+	}
+	header.Set(TraceParentHeader, FormatTraceParent(span.Context))
+}
@@ -0,0 +1,215 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	SpanContextKey = "trace_span"
+
+	ExporterOTLP = "otlp"
+	ExporterFile = "file"
+)
+
+type SpanKind int
+
+// values follow the OTLP span kind enumeration
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+type TraceID [16]byte
+type SpanID [8]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Sampled bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != TraceID{} && sc.SpanID != SpanID{}
+}
+
+type Span struct {
+	tracer *Tracer
+
+	Context    SpanContext
+	ParentID   SpanID
+	Name       string
+	Kind       SpanKind
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]interface{}
+	Err        error
+
+	mu    sync.Mutex
+	ended bool
+}
+
+// SetAttribute is safe to call on a nil span, which is returned when tracing is disabled.
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Attributes[key] = value
+}
+
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Err = err
+}
+
+func (s *Span) Finish() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.End = time.Now()
+	s.mu.Unlock()
+
+	if s.Context.Sampled {
+		s.tracer.processor.onEnd(s)
+	}
+}
+
+type Options struct {
+	ServiceName   string
+	Exporter      string
+	Endpoint      string
+	FilePath      string
+	SampleRatio   float64
+	BatchSize     int
+	FlushInterval time.Duration
+}
+
+type Tracer struct {
+	serviceName string
+	sampleRatio float64
+	processor   *batchProcessor
+}
+
+var global *Tracer
+
+// Init creates the tracer used by StartSpan. Until it is called spans are not recorded.
+func Init(options Options) (*Tracer, error) {
+	var exp exporter
+	var err error
+	switch options.Exporter {
+	case ExporterOTLP:
+		exp, err = newOTLPExporter(options.Endpoint, options.ServiceName)
+	case ExporterFile:
+		exp, err = newFileExporter(options.FilePath, options.ServiceName)
+	default:
+		err = fmt.Errorf("invalid tracing exporter: %s", options.Exporter)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if options.SampleRatio < 0 || options.SampleRatio > 1 {
+		return nil, fmt.Errorf("tracing sample ratio should be between 0 and 1: %v", options.SampleRatio)
+	}
+
+	global = &Tracer{
+		serviceName: options.ServiceName,
+		sampleRatio: options.SampleRatio,
+		processor:   newBatchProcessor(exp, options.BatchSize, options.FlushInterval),
+	}
+	return global, nil
+}
+
+// Close exports the remaining spans and releases the exporter.
+func (t *Tracer) Close() error {
+	return t.processor.close()
+}
+
+// StartSpan starts a span that is a child of the span stored in ctx, or a new trace root.
+// The returned span must be finished by the caller.
+func StartSpan(ctx context.Context, name string) (context.Context, *Span) {
+	return startSpan(ctx, name, KindInternal, SpanContext{})
+}
+
+func startSpan(ctx context.Context, name string, kind SpanKind, remote SpanContext) (context.Context, *Span) {
+	if global == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer:     global,
+		Name:       name,
+		Kind:       kind,
+		Start:      time.Now(),
+		Attributes: make(map[string]interface{}),
+	}
+
+	parent := remote
+	if p := SpanFromContext(ctx); p != nil {
+		parent = p.Context
+	}
+
+	if parent.IsValid() {
+		span.Context.TraceID = parent.TraceID
+		span.ParentID = parent.SpanID
+		span.Context.Sampled = parent.Sampled
+	} else {
+		span.Context.TraceID = newTraceID()
+		span.Context.Sampled = global.shouldSample(span.Context.TraceID)
+	}
+	span.Context.SpanID = newSpanID()
+
+	return context.WithValue(ctx, SpanContextKey, span), span
+}
+
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(SpanContextKey).(*Span)
+	return span
+}
+
+func (t *Tracer) shouldSample(traceID TraceID) bool {
+	if t.sampleRatio >= 1 {
+		return true
+	}
+	// the lower bytes of a random trace id are uniformly distributed
+	bound := uint64(t.sampleRatio * (1 << 63))
+	return binary.BigEndian.Uint64(traceID[8:])>>1 < bound
+}
+
+func newTraceID() TraceID {
+	var id TraceID
+	_, _ = rand.Read(id[:])
+	return id
+}
+
+func newSpanID() SpanID {
+	var id SpanID
+	_, _ = rand.Read(id[:])
+	return id
+}
@@ -0,0 +1,61 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+)
+
+var ErrForbiddenAddress = errors.New("webhook address is not public")
+
+// ValidateURL checks that rawURL is an absolute http or https URL whose host resolves
+// to public addresses only, so rules cannot point the service at internal endpoints.
+// The check is repeated on every dial, as the host may resolve differently later.
+// allowPrivate lifts the address restriction for local and private network deployments.
+func ValidateURL(ctx context.Context, rawURL string, allowPrivate bool) error {
+	webhookURL, err := url.Parse(rawURL)
+	if err != nil || webhookURL.Hostname() == "" ||
+		(webhookURL.Scheme != "http" && webhookURL.Scheme != "https") {
+		return fmt.Errorf("webhook url must be an absolute http or https URL")
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, webhookURL.Hostname())
+	if err != nil {
+		return fmt.Errorf("failed to resolve webhook host: %w", err)
+	}
+	for _, addr := range addrs {
+		if !allowPrivate && !publicIP(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenAddress, webhookURL.Hostname(), addr.IP)
+		}
+	}
+	return nil
+}
+
+// publicIP reports whether ip may be dialled: loopback, private, link-local,
+// multicast and unspecified addresses are not.
+func publicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// dialControl rejects connections to non-public addresses after name resolution,
+// which also covers redirects and hosts re-resolved since validation.
+func dialControl(allowPrivate bool) func(network, address string, _ syscall.RawConn) error {
+	return func(network, address string, _ syscall.RawConn) error {
+		if allowPrivate {
+			return nil
+		}
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			return err
+		}
+		ip := net.ParseIP(host)
+		if ip == nil || !publicIP(ip) {
+			return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestValidateURL(t *testing.T) {
+	tests := []struct {
+		name         string
+		url          string
+		allowPrivate bool
+		forbidden    bool
+		wantErr      bool
+	}{
+		{name: "relative", url: "/hook", wantErr: true},
+		{name: "unsupported scheme", url: "ftp://127.0.0.1/hook", wantErr: true},
+		{name: "loopback", url: "http://127.0.0.1:8080/hook", forbidden: true, wantErr: true},
+		{name: "localhost", url: "http://localhost/hook", forbidden: true, wantErr: true},
+		{name: "private", url: "https://10.0.0.5/hook", forbidden: true, wantErr: true},
+		{name: "link-local", url: "http://169.254.169.254/latest", forbidden: true, wantErr: true},
+		{name: "ipv6 loopback", url: "http://[::1]/hook", forbidden: true, wantErr: true},
+		{name: "unspecified", url: "http://0.0.0.0/hook", forbidden: true, wantErr: true},
+		{name: "public", url: "https://93.184.216.34/hook"},
+		{name: "loopback allowed", url: "http://127.0.0.1:8080/hook", allowPrivate: true},
+		{name: "private allowed", url: "https://10.0.0.5/hook", allowPrivate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateURL(context.Background(), tt.url, tt.allowPrivate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if errors.Is(err, ErrForbiddenAddress) != tt.forbidden {
+				t.Errorf("ValidateURL() error = %v, forbidden %v", err, tt.forbidden)
+			}
+		})
+	}
+}
+
+func TestDialControl(t *testing.T) {
+	tests := []struct {
+		address      string
+		allowPrivate bool
+		forbidden    bool
+	}{
+		{address: "93.184.216.34:443"},
+		{address: "[2606:2800:220:1:248:1893:25c8:1946]:443"},
+		{address: "127.0.0.1:80", forbidden: true},
+		{address: "192.168.1.10:80", forbidden: true},
+		{address: "[fe80::1]:80", forbidden: true},
+		{address: "224.0.0.1:80", forbidden: true},
+		{address: "127.0.0.1:80", allowPrivate: true},
+		{address: "192.168.1.10:80", allowPrivate: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.address, func(t *testing.T) {
+			err := dialControl(tt.allowPrivate)("tcp", tt.address, nil)
+			if errors.Is(err, ErrForbiddenAddress) != tt.forbidden {
+				t.Errorf("dialControl() error = %v, forbidden %v", err, tt.forbidden)
+			}
+		})
+	}
+}
+
+func TestPublicIP(t *testing.T) {
+	for ip, public := range map[string]bool{
+		"8.8.8.8":     true,
+		"10.1.2.3":    false,
+		"172.16.0.1":  false,
+		"ff02::1":     false,
+		"fc00::1":     false,
+		"::":          false,
+		"2001:4860::": true,
+	} {
+		if got := publicIP(net.ParseIP(ip)); got != public {
+			t.Errorf("publicIP(%s) = %v, want %v", ip, got, public)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"stats-service/pkg/logging"
+	"stats-service/pkg/tracing"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HeaderSignature carries "sha256=" followed by the hex HMAC-SHA256 of "<timestamp>.<body>"
+	// keyed with the shared secret. Receivers should recompute it and reject old timestamps.
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+type Options struct {
+	Secret         string
+	Timeout        time.Duration
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// AllowPrivateNetworks permits loopback and private endpoints, e.g. a local stub receiver.
+	AllowPrivateNetworks bool
+}
+
+// Client delivers JSON events to webhook endpoints. Network errors, 429 and 5xx responses
+// are retried with exponential backoff and jitter, other responses are final.
+type Client struct {
+	options Options
+	http    *http.Client
+	logger  *logging.Logger
+}
+
+func NewClient(options Options, logger *logging.Logger) *Client {
+	if options.MaxAttempts < 1 {
+		options.MaxAttempts = 1
+	}
+	dialer := &net.Dialer{Timeout: options.Timeout, Control: dialControl(options.AllowPrivateNetworks)}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// a proxy would be dialled instead of the endpoint and defeat the address check
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &Client{
+		options: options,
+		http:    &http.Client{Timeout: options.Timeout, Transport: transport},
+		logger:  logger,
+	}
+}
+
+// Sign returns the value of HeaderSignature for body sent at timestamp.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func (c *Client) Send(ctx context.Context, url, event string, payload interface{}) error {
+	ctx, span := tracing.StartSpan(ctx, "webhook.Send")
+	defer span.Finish()
+	span.SetAttribute("webhook.event", event)
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+	delivery, err := newDeliveryID()
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	logger := logging.LoggerFromContext(ctx, c.logger).GetLoggerWithField("delivery", delivery)
+	backoff := c.options.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := c.deliver(ctx, url, event, delivery, body)
+		if err == nil {
+			span.SetAttribute("webhook.attempts", attempt)
+			return nil
+		}
+		if !retry || attempt >= c.options.MaxAttempts {
+			span.RecordError(err)
+			return fmt.Errorf("webhook delivery %s failed after %d attempt(s): %w", delivery, attempt, err)
+		}
+
+		wait := backoff/2 + jitter(backoff/2)
+		logger.Warnf("webhook delivery attempt %d failed, retrying in %s: %v", attempt, wait, err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			span.RecordError(ctx.Err())
+			return fmt.Errorf("webhook delivery %s cancelled: %w", delivery, ctx.Err())
+		}
+
+		backoff *= 2
+		if backoff > c.options.MaxBackoff {
+			backoff = c.options.MaxBackoff
+		}
+	}
+}
+
+// deliver makes a single attempt and reports whether a failure is worth retrying.
+func (c *Client) deliver(ctx context.Context, url, event, delivery string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	// the timestamp is signed with the body so a captured request cannot be replayed later
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, event)
+	req.Header.Set(HeaderDelivery, delivery)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, Sign(c.options.Secret, timestamp, body))
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return ctx.Err() == nil && !errors.Is(err, ErrForbiddenAddress), err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook endpoint responded with %s", resp.Status)
+}
+
+func jitter(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(max)))
+	if err != nil {
+		return 0
+	}
+	return time.Duration(n.Int64())
+}
+
+func newDeliveryID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate delivery id: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
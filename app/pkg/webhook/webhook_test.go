@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"stats-service/pkg/logging"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestClient(options Options) *Client {
+	logging.InitLogger()
+	logging.SetOutput(io.Discard)
+	return NewClient(options, logging.GetLogger())
+}
+
+func TestSendSignature(t *testing.T) {
+	type received struct {
+		header http.Header
+		body   []byte
+	}
+	requests := make(chan received, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests <- received{header: r.Header.Clone(), body: body}
+	}))
+	defer server.Close()
+
+	client := newTestClient(Options{Secret: "secret", Timeout: time.Second, MaxAttempts: 1, AllowPrivateNetworks: true})
+	if err := client.Send(context.Background(), server.URL, "alert.triggered", map[string]int{"value": 1}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	request := <-requests
+	if got := string(request.body); got != `{"value":1}` {
+		t.Errorf("body = %s, want %s", got, `{"value":1}`)
+	}
+	if got := request.header.Get(HeaderEvent); got != "alert.triggered" {
+		t.Errorf("%s = %q, want %q", HeaderEvent, got, "alert.triggered")
+	}
+	if request.header.Get(HeaderDelivery) == "" {
+		t.Errorf("%s is empty", HeaderDelivery)
+	}
+	timestamp := request.header.Get(HeaderTimestamp)
+	want := Sign("secret", timestamp, request.body)
+	if got := request.header.Get(HeaderSignature); got != want {
+		t.Errorf("%s = %q, want %q", HeaderSignature, got, want)
+	}
+	if got := Sign("other", timestamp, request.body); got == want {
+		t.Errorf("signature does not depend on the secret")
+	}
+}
+
+func TestSendRetries(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		attempts int
+		wantErr  bool
+	}{
+		{name: "success", status: http.StatusNoContent, attempts: 1},
+		{name: "server error", status: http.StatusBadGateway, attempts: 3, wantErr: true},
+		{name: "too many requests", status: http.StatusTooManyRequests, attempts: 3, wantErr: true},
+		{name: "bad request", status: http.StatusBadRequest, attempts: 1, wantErr: true},
+		{name: "not found", status: http.StatusNotFound, attempts: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			attempts := 0
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				attempts++
+				mu.Unlock()
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client := newTestClient(Options{
+				Timeout:              time.Second,
+				MaxAttempts:          3,
+				InitialBackoff:       time.Millisecond,
+				MaxBackoff:           time.Millisecond,
+				AllowPrivateNetworks: true,
+			})
+			err := client.Send(context.Background(), server.URL, "alert.triggered", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Send() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			if attempts != tt.attempts {
+				t.Errorf("attempts = %d, want %d", attempts, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestSendBackoff(t *testing.T) {
+	var mu sync.Mutex
+	var times []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	const initial = 40 * time.Millisecond
+	client := newTestClient(Options{
+		Timeout:              time.Second,
+		MaxAttempts:          4,
+		InitialBackoff:       initial,
+		MaxBackoff:           time.Second,
+		AllowPrivateNetworks: true,
+	})
+	if err := client.Send(context.Background(), server.URL, "alert.triggered", nil); err == nil {
+		t.Fatal("Send() error = nil, want an error")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(times) != 4 {
+		t.Fatalf("attempts = %d, want 4", len(times))
+	}
+	// the wait before retry n is between half and all of initial*2^(n-1)
+	var gaps []time.Duration
+	for i := 1; i < len(times); i++ {
+		gap := times[i].Sub(times[i-1])
+		if min := initial << (i - 1) / 2; gap < min {
+			t.Errorf("wait before attempt %d = %s, want at least %s", i+1, gap, min)
+		}
+		gaps = append(gaps, gap)
+	}
+	if gaps[2] <= gaps[0] {
+		t.Errorf("waits %v do not grow", gaps)
+	}
+}
+
+func TestSendCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := newTestClient(Options{
+		Timeout:              time.Second,
+		MaxAttempts:          5,
+		InitialBackoff:       time.Minute,
+		MaxBackoff:           time.Minute,
+		AllowPrivateNetworks: true,
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := client.Send(ctx, server.URL, "alert.triggered", nil); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Send() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestSendPrivateNetworks(t *testing.T) {
+	var mu sync.Mutex
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		attempts++
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	client := newTestClient(Options{Timeout: time.Second, MaxAttempts: 3, InitialBackoff: time.Millisecond})
+	err := client.Send(context.Background(), server.URL, "alert.triggered", nil)
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("Send() error = %v, want %v", err, ErrForbiddenAddress)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if attempts != 0 {
+		t.Errorf("server received %d request(s), want none", attempts)
+	}
+}